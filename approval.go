@@ -0,0 +1,139 @@
+package alice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultApprovalTimeout = 30 * time.Minute
+
+// PendingAction is a scaling action waiting for someone to approve it, made when a manager scales in a direction
+// configured with scale_up or scale_down set to 'approval'
+type PendingAction struct {
+	ID        int       `json:"id"`
+	Direction string    `json:"direction"`
+	Step      int       `json:"step"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+}
+
+func (p PendingAction) String() string {
+	return fmt.Sprintf("Scale %s by %d, waiting for approval until %s", p.Direction, p.Step, p.Until.Format(time.RFC3339))
+}
+
+// scaleMode is how the manager scales in the given direction: "enabled", "disabled" (advisory mode) or "approval"
+func (m *Manager) scaleMode(direction string) string {
+	key := "scale_" + direction
+	switch {
+	case !m.Config.IsSet(key):
+		// Scaling is enabled unless it's turned off
+		return "enabled"
+	case m.Config.GetString(key) == "approval":
+		return "approval"
+	case m.Config.GetBool(key):
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// requestApproval records a pending action to scale by step in the given direction, unless one is already waiting. A
+// pending action in the other direction is replaced. Only the leader asks for approval, as approvals must be sent to
+// it. What happened is noted in the run's record.
+func (m *Manager) requestApproval(ctx context.Context, run *AuditRecord, direction string, step int) error {
+	if m.Elector != nil && !m.Elector.IsLeader() {
+		return m.change(ctx, run, direction, step)
+	}
+	run.Direction, run.Step = direction, step
+	m.mu.Lock()
+	pending := m.currentPending()
+	if pending == nil || pending.Direction != direction {
+		timeout := defaultApprovalTimeout
+		if m.Config.IsSet("approval_timeout") {
+			timeout = m.Config.GetDuration("approval_timeout")
+		}
+		m.approvals++
+		m.pending = &PendingAction{ID: m.approvals, Direction: direction, Step: step, Since: run.Time, Until: run.Time.Add(timeout)}
+		pending = m.pending
+		m.Logger.Warnf("Asking for approval to scale %s by %d, until %s", direction, step, pending.Until.Format(time.RFC3339))
+	} else {
+		m.Logger.Infof("Still waiting for approval to scale %s by %d", pending.Direction, pending.Step)
+	}
+	run.Action, run.Reason = ActionPending, pending.String()
+	m.mu.Unlock()
+	return nil
+}
+
+// Pending returns the scaling action waiting for approval, if there is one
+func (m *Manager) Pending() *PendingAction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.currentPending()
+}
+
+// currentPending returns the pending action, forgetting it if it has expired. m.mu must be held.
+func (m *Manager) currentPending() *PendingAction {
+	if m.pending == nil {
+		return nil
+	}
+	if !m.now().Before(m.pending.Until) {
+		m.Logger.Warnf("No longer waiting for approval to scale %s by %d, the request has expired", m.pending.Direction, m.pending.Step)
+		m.pending = nil
+		return nil
+	}
+	pending := *m.pending
+	return &pending
+}
+
+// Approve carries out the pending action. If id isn't 0 it must match the pending action's, so that an action
+// replaced since it was looked at isn't approved by mistake. The inventory's status is checked again first, and the
+// action is subject to the usual limits. Only the leader will scale.
+func (m *Manager) Approve(ctx context.Context, id int, reason string) error {
+	m.running.Lock()
+	defer m.running.Unlock()
+	m.mu.Lock()
+	pending := m.currentPending()
+	m.mu.Unlock()
+	if pending == nil {
+		return errors.New("Nothing is waiting for approval")
+	}
+	if id != 0 && id != pending.ID {
+		return errors.Errorf("Action %d is no longer waiting for approval, action %d is", id, pending.ID)
+	}
+	status, err := m.Inventory.Status(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Can't check the inventory status")
+	}
+	if status != OK {
+		return errors.Errorf("The inventory is %s, try again once it is OK", status)
+	}
+	m.mu.Lock()
+	m.pending = nil
+	m.mu.Unlock()
+
+	run := AuditRecord{Time: m.now(), Manager: m.Name, Action: ActionNone, Reason: "Approved: " + reason}
+	m.Logger.Warnf("Approved scale %s by %d: %s", pending.Direction, pending.Step, reason)
+	err = m.change(ctx, &run, pending.Direction, pending.Step)
+	if err == nil && run.Action == ActionScaled {
+		run.Action = ActionApproved
+	} else if err == nil {
+		err = errors.New(run.Reason)
+	}
+	m.record(ctx, &run, err)
+	return err
+}
+
+// Reject forgets the pending action without carrying it out
+func (m *Manager) Reject(reason string) error {
+	m.mu.Lock()
+	pending := m.currentPending()
+	m.pending = nil
+	m.mu.Unlock()
+	if pending == nil {
+		return errors.New("Nothing is waiting for approval")
+	}
+	m.Logger.Warnf("Rejected scale %s by %d: %s", pending.Direction, pending.Step, reason)
+	return nil
+}
@@ -0,0 +1,83 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupApprovalTest() (*alice.Manager, *MockInventory, *time.Time) {
+	man, inv, str, now := setupOverrideTest()
+	man.Config.Set("scale_up", "approval")
+	man.Config.Set("approval_timeout", "1h")
+	inv.On("Total").Return(10, nil)
+	up := alice.Recommendation(2)
+	str.On("Evaluate").Return(&up, nil)
+	return man, inv, now
+}
+
+func TestManager_Approve(t *testing.T) {
+	man, inv, now := setupApprovalTest()
+	notifier := MockNotifier{}
+	notifier.On("Notify", mock.Anything).Return(nil)
+	man.Notifier = &notifier
+	inv.On("Status").Return(alice.UPDATING, nil).Times(3)
+
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertNotCalled(t, "Increase", 2)
+	status := man.Status()
+	assert.Equal(t, alice.ActionPending, status.Action)
+	assert.Equal(t, &alice.PendingAction{ID: 1, Direction: "up", Step: 2, Since: *now, Until: now.Add(time.Hour)}, status.Pending)
+
+	// Later runs keep waiting for the same approval, which is only asked for once
+	*now = now.Add(time.Minute)
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, 1, man.Pending().ID)
+	assert.Len(t, notifier.events(), 1)
+	assert.Equal(t, alice.EventApprovalNeeded, notifier.events()[0].Type)
+	assert.Equal(t, 1, notifier.events()[0].Pending.ID)
+
+	assert.EqualError(t, man.Approve(ctx, 2, ""), "Action 2 is no longer waiting for approval, action 1 is")
+	assert.EqualError(t, man.Approve(ctx, 1, ""), "The inventory is UPDATING, try again once it is OK")
+	assert.NotNil(t, man.Pending())
+
+	inv.On("Status").Return(alice.OK, nil)
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Approve(ctx, 1, "Looks right"))
+	assert.Equal(t, alice.ActionApproved, man.Status().Action)
+	assert.Equal(t, "Approved: Looks right", man.Status().Reason)
+	assert.Nil(t, man.Pending())
+	assert.Equal(t, alice.EventScaledUp, notifier.events()[1].Type)
+	inv.AssertExpectations(t)
+
+	assert.EqualError(t, man.Approve(ctx, 0, ""), "Nothing is waiting for approval")
+}
+
+func TestManager_ApprovalExpires(t *testing.T) {
+	man, inv, now := setupApprovalTest()
+	inv.On("Status").Return(alice.OK, nil)
+	assert.NoError(t, man.Run(ctx))
+	assert.NotNil(t, man.Pending())
+
+	*now = now.Add(time.Hour)
+	assert.Nil(t, man.Pending())
+	assert.Error(t, man.Approve(ctx, 1, ""))
+
+	// A new approval is asked for if the strategy still recommends it
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, 2, man.Pending().ID)
+	inv.AssertNotCalled(t, "Increase", 2)
+}
+
+func TestManager_Reject(t *testing.T) {
+	man, inv, _ := setupApprovalTest()
+	inv.On("Status").Return(alice.OK, nil)
+	assert.NoError(t, man.Run(ctx))
+	assert.NoError(t, man.Reject("Not during the sale"))
+	assert.Nil(t, man.Pending())
+	assert.Error(t, man.Reject(""))
+	inv.AssertNotCalled(t, "Increase", 2)
+}
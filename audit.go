@@ -0,0 +1,143 @@
+package alice
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Action is what a Manager did with a recommendation during a run
+type Action string
+
+const (
+	// ActionNone - the strategy recommended holding, or failed to make a recommendation
+	ActionNone Action = "none"
+	// ActionUnconfirmed - the recommendation has not persisted for long enough to act on it yet
+	ActionUnconfirmed Action = "unconfirmed"
+	// ActionAdvisory - scaling in the recommended direction is disabled, so nothing was changed
+	ActionAdvisory Action = "advisory"
+	// ActionScaled - the inventory was scaled
+	ActionScaled Action = "scaled"
+	// ActionRefused - the inventory refused to scale
+	ActionRefused Action = "refused"
+	// ActionPaused - the manager has been paused, so nothing was changed
+	ActionPaused Action = "paused"
+	// ActionPinned - the manager has been pinned, so the inventory was scaled to the pinned total if it wasn't already there
+	ActionPinned Action = "pinned"
+	// ActionForced - the inventory was scaled on request, regardless of the strategy
+	ActionForced Action = "forced"
+	// ActionRateLimited - too many scaling actions have been taken recently, so nothing was changed
+	ActionRateLimited Action = "rate_limited"
+	// ActionPending - scaling in the recommended direction needs approval, which is being waited for
+	ActionPending Action = "pending"
+	// ActionApproved - the inventory was scaled once someone approved it
+	ActionApproved Action = "approved"
+	// ActionStale - some metrics were older than max_metric_age, so the manager held rather than scale on them
+	ActionStale Action = "stale"
+)
+
+// AuditRecord describes everything a Manager saw and did during a single run
+type AuditRecord struct {
+	Time           time.Time                `json:"time"`
+	Manager        string                   `json:"manager"`
+	Metrics        []MetricUpdate           `json:"metrics"`
+	Recommendation *Recommendation          `json:"recommendation"`
+	Strategies     []StrategyRecommendation `json:"strategies,omitempty"`
+	Evaluation     *Evaluation              `json:"evaluation,omitempty"`
+	Action         Action                   `json:"action"`
+	Direction      string                   `json:"direction,omitempty"`
+	Step           int                      `json:"step,omitempty"`
+	Reason         string                   `json:"reason,omitempty"`
+	TotalBefore    *int                     `json:"total_before"`
+	TotalAfter     *int                     `json:"total_after"`
+	Error          string                   `json:"error,omitempty"`
+}
+
+// AuditLog appends AuditRecords to a file as JSON, one per line. Once the file grows beyond its maximum size it is
+// rotated to <path>.1, <path>.2 etc, keeping at most maxBackups old files. It is safe for concurrent use by several
+// managers.
+type AuditLog struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+// NewAuditLog opens (or creates) an audit log at path. A maxSize of 0 disables rotation.
+func NewAuditLog(path string, maxSize int64, maxBackups int) (*AuditLog, error) {
+	a := &AuditLog{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Write appends a record to the log, rotating the file first if it would grow too large
+func (a *AuditLog) Write(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// Close closes the underlying file
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+func (a *AuditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file, a.size = f, info.Size()
+	return nil
+}
+
+func (a *AuditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	if a.maxBackups < 1 {
+		if err := os.Remove(a.path); err != nil {
+			return err
+		}
+		return a.open()
+	}
+	os.Remove(a.backup(a.maxBackups))
+	for i := a.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(a.backup(i), a.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(a.path, a.backup(1)); err != nil {
+		return err
+	}
+	return a.open()
+}
+
+func (a *AuditLog) backup(n int) string {
+	return fmt.Sprintf("%s.%d", a.path, n)
+}
@@ -0,0 +1,83 @@
+package alice_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAuditLog(t *testing.T, path string) []alice.AuditRecord {
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	var records []alice.AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record alice.AuditRecord
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditLog_Rotate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "alice")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+	audit, err := alice.NewAuditLog(path, 200, 2)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, audit.Write(alice.AuditRecord{Manager: "test", Action: alice.ActionNone}))
+	}
+	assert.NoError(t, audit.Close())
+	assert.Len(t, readAuditLog(t, path), 1)
+	assert.Len(t, readAuditLog(t, path+".1"), 1)
+	assert.Len(t, readAuditLog(t, path+".2"), 1)
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestManager_RunWritesAuditLog(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "alice")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+	audit, _ := alice.NewAuditLog(path, 0, 0)
+
+	config := viper.New()
+	config.Set("scale_down", false)
+	inv := MockInventory{}
+	inv.On("Total").Return(4, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	str := MockStrategy{}
+	man := alice.Manager{Name: "audited", Strategy: &str, Inventory: &inv, Logger: log, Config: config, Audit: audit}
+	up, down := alice.SCALEUP, alice.SCALEDOWN
+
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 1).Return(nil).Once()
+	man.Run(ctx)
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 1).Return(errors.New("Won't scale servers while changes are in progress")).Once()
+	man.Run(ctx)
+	str.On("Evaluate").Return(&down, nil).Once()
+	man.Run(ctx)
+	audit.Close()
+
+	records := readAuditLog(t, path)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "audited", records[0].Manager)
+	assert.Equal(t, alice.ActionScaled, records[0].Action)
+	assert.Equal(t, "up", records[0].Direction)
+	assert.Equal(t, 4, *records[0].TotalBefore)
+	assert.Equal(t, alice.ActionRefused, records[1].Action)
+	assert.Equal(t, "Won't scale servers while changes are in progress", records[1].Reason)
+	assert.Equal(t, alice.ActionAdvisory, records[2].Action)
+	assert.Equal(t, alice.SCALEDOWN, *records[2].Recommendation)
+}
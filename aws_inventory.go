@@ -1,7 +1,9 @@
 package alice
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -36,21 +38,24 @@ type AWSMetadata struct {
 	instanceID   string
 }
 
-const (
-	defaultAWSRegion        = "eu-west-1"
-	defaultSettleDownPeriod = "0s"
-)
+// AWSConfig is the configuration of an AWSInventory
+type AWSConfig struct {
+	Region           string        `config:"region" default:"eu-west-1" description:"AWS region of the autoscaling group"`
+	SettleDownPeriod time.Duration `config:"settle_down_period" default:"0s" description:"How long to wait after scaling before scaling again"`
+	Timeout          time.Duration `config:"timeout" default:"30s" description:"Give up on a call to AWS after this long"`
+}
 
 // NewAWSInventory creates a new AWSInventory
 func NewAWSInventory(config *viper.Viper, log *logrus.Entry) (Inventory, error) {
-	config.SetDefault("region", defaultAWSRegion)
-	config.SetDefault("settle_down_period", defaultSettleDownPeriod)
+	var c AWSConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
 	s, err := session.NewSession()
 	if err != nil {
 		return nil, err
 	}
-	region := config.GetString("region")
-	s.Config.Region = &region
+	s.Config.Region = &c.Region
 	inv := AWSInventory{
 		AutoscalingSvc: autoscaling.New(s),
 		EC2metadataSvc: ec2metadata.New(s),
@@ -61,32 +66,44 @@ func NewAWSInventory(config *viper.Viper, log *logrus.Entry) (Inventory, error)
 }
 
 // Total returns the current total number of resources
-func (a *AWSInventory) Total() (int, error) {
-	name := a.GroupName()
+func (a *AWSInventory) Total(ctx context.Context) (int, error) {
+	name, err := a.GroupName(ctx)
+	if err != nil {
+		return 0, err
+	}
 	params := &autoscaling.DescribeAutoScalingGroupsInput{
 		AutoScalingGroupNames: []*string{&name},
 	}
-	group := a.describeAutoScalingGroups(params)
+	group, err := a.describeAutoScalingGroups(ctx, params)
+	if err != nil {
+		return 0, err
+	}
 	return len(group.Instances), nil
 }
 
-// Increase (scale up) the number of resources in the inventory
-func (a *AWSInventory) Increase() error {
-	return a.Scale(+1)
+// Increase (scale up) the number of resources in the inventory by amount
+func (a *AWSInventory) Increase(ctx context.Context, amount int) error {
+	return a.Scale(ctx, +amount)
 }
 
-// Decrease (scale down) the number of resources in the inventory
-func (a *AWSInventory) Decrease() error {
-	return a.Scale(-1)
+// Decrease (scale down) the number of resources in the inventory by amount
+func (a *AWSInventory) Decrease(ctx context.Context, amount int) error {
+	return a.Scale(ctx, -amount)
 }
 
 // Status returns OK if the inventory is ready to be scaled, UPDATING if an update is in progress, or FAILED
-func (a *AWSInventory) Status() (Status, error) {
-	params := &autoscaling.DescribeScalingActivitiesInput{AutoScalingGroupName: aws.String(a.GroupName())}
+func (a *AWSInventory) Status(ctx context.Context) (Status, error) {
+	name, err := a.GroupName(ctx)
+	if err != nil {
+		return FAILED, err
+	}
+	params := &autoscaling.DescribeScalingActivitiesInput{AutoScalingGroupName: aws.String(name)}
 	status := OK
 	done := false
 	for !done {
-		resp, err := a.AutoscalingSvc.DescribeScalingActivities(params)
+		callCtx, cancel := context.WithTimeout(ctx, a.Config.GetDuration("timeout"))
+		resp, err := a.AutoscalingSvc.DescribeScalingActivitiesWithContext(callCtx, params)
+		cancel()
 		if err != nil {
 			return status, err
 		}
@@ -119,14 +136,19 @@ func (a *AWSInventory) Status() (Status, error) {
 	return status, nil
 }
 
-func (a *AWSInventory) describeAutoScalingGroups(params *autoscaling.DescribeAutoScalingGroupsInput) *autoscaling.Group {
-	a.RefreshMetadata()
+// describeAutoScalingGroups finds the autoscaling group this instance belongs to, out of those matching params
+func (a *AWSInventory) describeAutoScalingGroups(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.Group, error) {
+	if err := a.RefreshMetadata(); err != nil {
+		return nil, err
+	}
 	var group *autoscaling.Group
 	done := false
 	for !done {
-		resp, err := a.AutoscalingSvc.DescribeAutoScalingGroups(params)
+		callCtx, cancel := context.WithTimeout(ctx, a.Config.GetDuration("timeout"))
+		resp, err := a.AutoscalingSvc.DescribeAutoScalingGroupsWithContext(callCtx, params)
+		cancel()
 		if err != nil {
-			a.log.Fatalf("describeAutoScalingGroups: %v", err)
+			return nil, fmt.Errorf("describeAutoScalingGroups: %v", err)
 		}
 
 		for _, scaleGroup := range resp.AutoScalingGroups {
@@ -144,24 +166,27 @@ func (a *AWSInventory) describeAutoScalingGroups(params *autoscaling.DescribeAut
 	}
 
 	if group == nil {
-		a.log.Fatal("No auto scaling group available")
+		return nil, errors.New("No auto scaling group available")
 	}
-	return group
+	return group, nil
 }
 
 // GroupName returns the autoscaling group for this inventory
-func (a *AWSInventory) GroupName() string {
+func (a *AWSInventory) GroupName(ctx context.Context) (string, error) {
 	if a.groupName == "" {
-		group := a.describeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{})
+		group, err := a.describeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{})
+		if err != nil {
+			return "", err
+		}
 		a.groupName = *group.AutoScalingGroupName
 	}
-	return a.groupName
+	return a.groupName, nil
 }
 
 // Scale attempts to increase the number of instances by the amount specified
-func (a *AWSInventory) Scale(amount int) error {
+func (a *AWSInventory) Scale(ctx context.Context, amount int) error {
 	// Check inventory status before trying to scale anything
-	status, err := a.Status()
+	status, err := a.Status(ctx)
 	if err != nil {
 		return err
 	}
@@ -172,7 +197,10 @@ func (a *AWSInventory) Scale(amount int) error {
 	case FAILED:
 		err = errors.New("Won't scale servers while something seems to be in a failed state")
 	case OK:
-		group := a.describeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{})
+		var group *autoscaling.Group
+		if group, err = a.describeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{}); err != nil {
+			break
+		}
 		currentCapacity := *group.DesiredCapacity
 		a.log.Infof("Current capacity is: %d", currentCapacity)
 		newCapacity := currentCapacity + int64(amount)
@@ -182,31 +210,40 @@ func (a *AWSInventory) Scale(amount int) error {
 			err = errors.New("Attempt to scale below minimum capacity denied")
 			break
 		}
+		if group.MaxSize != nil && newCapacity > *group.MaxSize {
+			err = errors.New("Attempt to scale above maximum capacity denied")
+			break
+		}
 		scalingParams := &autoscaling.SetDesiredCapacityInput{
-			AutoScalingGroupName: aws.String(a.GroupName()),
+			AutoScalingGroupName: group.AutoScalingGroupName,
 			DesiredCapacity:      aws.Int64(newCapacity),
 			HonorCooldown:        aws.Bool(false),
 		}
-		_, err = a.AutoscalingSvc.SetDesiredCapacity(scalingParams)
+		callCtx, cancel := context.WithTimeout(ctx, a.Config.GetDuration("timeout"))
+		_, err = a.AutoscalingSvc.SetDesiredCapacityWithContext(callCtx, scalingParams)
+		cancel()
 	default:
 		err = errors.New("Unknown status")
 	}
 	if err == nil {
-		a.log.Infof("Scaling %v by %v", a.GroupName(), amount)
+		a.log.Infof("Scaling %v by %v", a.groupName, amount)
 		a.lastModified = time.Now()
 	}
 	return err
 }
 
 // RefreshMetadata pulls updated metadata
-func (a *AWSInventory) RefreshMetadata() {
+func (a *AWSInventory) RefreshMetadata() error {
 	instanceID, err := a.EC2metadataSvc.GetMetadata("instance-id")
 	if err != nil {
-		a.log.Fatal(err)
+		return fmt.Errorf("Can't get instance id from EC2 metadata: %v", err)
 	}
 	regionWithAZ, err := a.EC2metadataSvc.GetMetadata("placement/availability-zone")
 	if err != nil {
-		a.log.Fatal(err)
+		return fmt.Errorf("Can't get availability zone from EC2 metadata: %v", err)
+	}
+	if regionWithAZ == "" {
+		return errors.New("EC2 metadata returned an empty availability zone")
 	}
 
 	// Strip the AZ from the regionWithAZ to get the region
@@ -217,4 +254,5 @@ func (a *AWSInventory) RefreshMetadata() {
 		regionWithAZ: regionWithAZ,
 		region:       region,
 	}
+	return nil
 }
@@ -1,8 +1,10 @@
 package alice_test
 
 import (
+	"errors"
 	"github.com/Sirupsen/logrus"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/notonthehighstreet/alice"
@@ -17,19 +19,19 @@ type MockAutoScalingClient struct {
 	autoscalingiface.AutoScalingAPI
 }
 
-func (m *MockAutoScalingClient) DescribeAutoScalingGroups(p *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+func (m *MockAutoScalingClient) DescribeAutoScalingGroupsWithContext(_ aws.Context, p *autoscaling.DescribeAutoScalingGroupsInput, _ ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
 	args := m.Mock.Called()
 	output := args.Get(0).(autoscaling.DescribeAutoScalingGroupsOutput)
 	return &output, args.Error(1)
 }
 
-func (m *MockAutoScalingClient) SetDesiredCapacity(p *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error) {
+func (m *MockAutoScalingClient) SetDesiredCapacityWithContext(_ aws.Context, p *autoscaling.SetDesiredCapacityInput, _ ...request.Option) (*autoscaling.SetDesiredCapacityOutput, error) {
 	args := m.Mock.Called()
 	output := autoscaling.SetDesiredCapacityOutput{}
 	return &output, args.Error(0)
 }
 
-func (m *MockAutoScalingClient) DescribeScalingActivities(p *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+func (m *MockAutoScalingClient) DescribeScalingActivitiesWithContext(_ aws.Context, p *autoscaling.DescribeScalingActivitiesInput, _ ...request.Option) (*autoscaling.DescribeScalingActivitiesOutput, error) {
 	args := m.Mock.Called()
 	output := args.Get(0).(*autoscaling.DescribeScalingActivitiesOutput)
 	return output, args.Error(1)
@@ -61,6 +63,7 @@ func setupAWSInventoryTest() {
 			AutoScalingGroupName: aws.String("foo"),
 			DesiredCapacity:      aws.Int64(10),
 			MinSize:              aws.Int64(1),
+			MaxSize:              aws.Int64(20),
 		},
 	}
 	asg.NextToken = nil
@@ -81,9 +84,9 @@ func setupAWSInventoryTest() {
 	asgScalingActivities.NextToken = nil
 	mockEc2MetadataClient.On("GetMetadata", "instance-id").Return("i-12345678", nil)
 	mockEc2MetadataClient.On("GetMetadata", "placement/availability-zone").Return("eu-west-1b", nil)
-	mockAutoscalingClient.On("DescribeAutoScalingGroups").Return(asg, nil)
-	mockAutoscalingClient.On("DescribeScalingActivities").Return(&asgScalingActivities, nil)
-	mockAutoscalingClient.On("SetDesiredCapacity").Return(nil)
+	mockAutoscalingClient.On("DescribeAutoScalingGroupsWithContext").Return(asg, nil)
+	mockAutoscalingClient.On("DescribeScalingActivitiesWithContext").Return(&asgScalingActivities, nil)
+	mockAutoscalingClient.On("SetDesiredCapacityWithContext").Return(nil)
 	log.Logger.Level = logrus.DebugLevel
 
 	i, _ := alice.NewAWSInventory(viper.New(), log)
@@ -94,34 +97,62 @@ func setupAWSInventoryTest() {
 
 func TestAWSInventory_Scale(t *testing.T) {
 	setupAWSInventoryTest()
-	err := AWSInv.Scale(1)
+	err := AWSInv.Scale(ctx, 1)
 	assert.Nil(t, err)
+	assert.Error(t, AWSInv.Scale(ctx, -10))
+	assert.Error(t, AWSInv.Scale(ctx, 11))
 }
 
 func TestAWSInventory_GroupName(t *testing.T) {
 	setupAWSInventoryTest()
-	name := AWSInv.GroupName()
+	name, err := AWSInv.GroupName(ctx)
+	assert.NoError(t, err)
 	assert.Equal(t, name, "foo")
 }
 
+func TestAWSInventory_Errors(t *testing.T) {
+	setupAWSInventoryTest()
+	metadata := MockEC2MetadataClient{}
+	metadata.On("GetMetadata", "instance-id").Return("", errors.New("Metadata unavailable"))
+	AWSInv.EC2metadataSvc = &metadata
+	_, err := AWSInv.Total(ctx)
+	assert.Error(t, err)
+	assert.Error(t, AWSInv.Increase(ctx, 1))
+
+	// An instance that isn't in any group
+	unknown := MockEC2MetadataClient{}
+	unknown.On("GetMetadata", "instance-id").Return("i-87654321", nil)
+	unknown.On("GetMetadata", "placement/availability-zone").Return("eu-west-1b", nil)
+	AWSInv.EC2metadataSvc = &unknown
+	_, err = AWSInv.GroupName(ctx)
+	assert.EqualError(t, err, "No auto scaling group available")
+
+	client := MockAutoScalingClient{}
+	client.On("DescribeAutoScalingGroupsWithContext").Return(autoscaling.DescribeAutoScalingGroupsOutput{}, errors.New("Throttled"))
+	AWSInv.AutoscalingSvc = &client
+	AWSInv.EC2metadataSvc = &mockEc2MetadataClient
+	_, err = AWSInv.Status(ctx)
+	assert.Error(t, err)
+}
+
 func TestAWSInventory_Total(t *testing.T) {
 	setupAWSInventoryTest()
-	total, _ := AWSInv.Total()
+	total, _ := AWSInv.Total(ctx)
 	assert.Equal(t, total, 1)
 }
 
 func TestAWSInventory_Increase(t *testing.T) {
 	setupAWSInventoryTest()
-	assert.Nil(t, AWSInv.Increase())
+	assert.Nil(t, AWSInv.Increase(ctx, 1))
 	asgScalingActivities.Activities[0].StatusCode = aws.String(autoscaling.ScalingActivityStatusCodeInProgress)
-	assert.Error(t, AWSInv.Increase())
+	assert.Error(t, AWSInv.Increase(ctx, 1))
 }
 
 func TestAWSInventory_Decrease(t *testing.T) {
 	setupAWSInventoryTest()
-	assert.Nil(t, AWSInv.Decrease())
+	assert.Nil(t, AWSInv.Decrease(ctx, 1))
 	asgScalingActivities.Activities[0].StatusCode = aws.String(autoscaling.ScalingActivityStatusCodeInProgress)
-	assert.Error(t, AWSInv.Decrease())
+	assert.Error(t, AWSInv.Decrease(ctx, 1))
 }
 
 func TestAWSInventory_Status(t *testing.T) {
@@ -134,22 +165,22 @@ func TestAWSInventory_Status(t *testing.T) {
 		StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeInProgress),
 	}
 	setupAWSInventoryTest()
-	status, _ := AWSInv.Status()
+	status, _ := AWSInv.Status(ctx)
 	assert.Equal(t, alice.OK, status)
 	asgScalingActivities.Activities = append(asgScalingActivities.Activities, updatingActivity)
-	status, _ = AWSInv.Status()
+	status, _ = AWSInv.Status(ctx)
 	assert.Equal(t, alice.UPDATING, status)
 	asgScalingActivities.Activities = append(asgScalingActivities.Activities, failedActivity)
-	status, _ = AWSInv.Status()
+	status, _ = AWSInv.Status(ctx)
 	assert.Equal(t, alice.FAILED, status)
 }
 
 func TestAWSInventory_SettleDownTime(t *testing.T) {
 	setupAWSInventoryTest()
 	AWSInv.Config.Set("settle_down_period", "5m")
-	assert.Nil(t, AWSInv.Increase())
-	status, _ := AWSInv.Status()
+	assert.Nil(t, AWSInv.Increase(ctx, 1))
+	status, _ := AWSInv.Status(ctx)
 	assert.Equal(t, alice.UPDATING, status)
-	assert.Error(t, AWSInv.Decrease())
-	assert.Error(t, AWSInv.Increase())
+	assert.Error(t, AWSInv.Decrease(ctx, 1))
+	assert.Error(t, AWSInv.Increase(ctx, 1))
 }
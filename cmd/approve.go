@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	conf "github.com/spf13/viper"
+)
+
+// approve carries out the scaling action a manager is waiting for approval of, through the status API of the running
+// copy of alice. It returns the exit code.
+func approve(args []string) int {
+	return decide("approve", args)
+}
+
+// reject forgets the scaling action a manager is waiting for approval of. It returns the exit code.
+func reject(args []string) int {
+	return decide("reject", args)
+}
+
+// decide sends an approve or reject control for a manager to the status API
+func decide(control string, args []string) int {
+	o := newOptions(control, false)
+	url := o.String("url", "", "URL of the running alice's status API (default from http.listen in the config)")
+	reason := o.String("reason", "", "Why, for the logs and audit log")
+	id := o.Int("id", 0, "Only "+control+" the pending action with this id, in case it has been replaced")
+	token := o.String("token", "", "Token for controlling managers through the status API (default http.token in the config)")
+	o.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: alice %s [flags] <manager>\n", control)
+		o.PrintDefaults()
+	}
+	o.Parse(args)
+	if o.NArg() != 1 {
+		o.Usage()
+		return 2
+	}
+	if *url == "" || *token == "" {
+		o.setup(true)
+	}
+	if *token == "" {
+		*token = conf.GetString("http.token")
+	}
+	if *url == "" {
+		if !conf.IsSet("http.listen") {
+			fmt.Fprintln(os.Stderr, "The status API isn't enabled with http.listen, so give its --url")
+			return 1
+		}
+		*url = "http://" + conf.GetString("http.listen")
+		if strings.HasPrefix(conf.GetString("http.listen"), ":") {
+			*url = "http://localhost" + conf.GetString("http.listen")
+		}
+	}
+
+	name := o.Arg(0)
+	body, _ := json.Marshal(map[string]interface{}{"id": *id, "reason": *reason})
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(*url, "/")+"/managers/"+name+"/"+control, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --url: %s\n", err.Error())
+		return 1
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+*token)
+	client := http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error contacting alice: %s\n", err.Error())
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		fmt.Fprintf(os.Stderr, "Can't %s: %s\n", control, failure.Error)
+		return 1
+	}
+	var status alice.ManagerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading response: %s\n", err.Error())
+		return 1
+	}
+	if control == "reject" {
+		fmt.Printf("Rejected the pending action of %s\n", name)
+	} else if status.InventoryTotal != nil {
+		fmt.Printf("Approved, %s now has %d\n", name, *status.InventoryTotal)
+	} else {
+		fmt.Printf("Approved the pending action of %s\n", name)
+	}
+	return 0
+}
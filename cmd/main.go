@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"github.com/Sirupsen/logrus"
 	"github.com/evalphobia/logrus_fluent"
+	"github.com/fsnotify/fsnotify"
 	"github.com/heirko/go-contrib/logrusHelper"
 	"github.com/johntdyer/slackrus"
 	"github.com/notonthehighstreet/alice"
 	conf "github.com/spf13/viper"
-	"sync"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -19,40 +29,312 @@ func init() {
 	alice.RegisterMonitor("fake", alice.NewFakeMonitor)
 	alice.RegisterMonitor("mesos", alice.NewMesosMonitor)
 	alice.RegisterMonitor("datadog", alice.NewDatadogMonitor)
+	alice.RegisterStrategy("composite", alice.NewCompositeStrategy)
 	alice.RegisterStrategy("ratio", alice.NewRatioStrategy)
 	alice.RegisterStrategy("threshold", alice.NewThresholdStrategy)
+	alice.RegisterElector("consul", alice.NewConsulElector)
+	alice.RegisterElector("file", alice.NewFileElector)
+	alice.RegisterNotifier("pagerduty", alice.NewPagerDutyNotifier)
+	alice.RegisterNotifier("slack", alice.NewSlackNotifier)
+	alice.RegisterNotifier("smtp", alice.NewSMTPNotifier)
+	alice.RegisterNotifier("webhook", alice.NewWebhookNotifier)
 
-	// Setup config
-	conf.AddConfigPath("./config")
-	if err := conf.ReadInConfig(); err != nil {
-		logrus.Panicf("Fatal error config file: %s \n", err)
+	// Describe the configuration they take, so it can be checked up front and documented
+	alice.RegisterConfig(alice.InventoryPlugin, "aws", alice.AWSConfig{})
+	alice.RegisterConfig(alice.InventoryPlugin, "marathon", alice.MarathonConfig{})
+	alice.RegisterConfig(alice.MonitorPlugin, "fake", alice.FakeMonitorConfig{})
+	alice.RegisterConfig(alice.MonitorPlugin, "mesos", alice.MesosConfig{})
+	alice.RegisterConfig(alice.MonitorPlugin, "datadog", alice.DatadogConfig{})
+	alice.RegisterConfig(alice.StrategyPlugin, "composite", alice.CompositeConfig{})
+	alice.RegisterConfig(alice.StrategyPlugin, "ratio", alice.RatioConfig{})
+	alice.RegisterConfig(alice.StrategyPlugin, "threshold", alice.ThresholdConfig{})
+	alice.RegisterConfig(alice.ElectorPlugin, "consul", alice.ConsulConfig{})
+	alice.RegisterConfig(alice.ElectorPlugin, "file", alice.FileElectorConfig{})
+	alice.RegisterConfig(alice.NotifierPlugin, "pagerduty", alice.PagerDutyConfig{})
+	alice.RegisterConfig(alice.NotifierPlugin, "slack", alice.SlackConfig{})
+	alice.RegisterConfig(alice.NotifierPlugin, "smtp", alice.SMTPConfig{})
+	alice.RegisterConfig(alice.NotifierPlugin, "webhook", alice.WebhookConfig{})
+}
+
+// commands are the subcommands alice understands. Each one takes the arguments after its name and returns an exit code.
+var commands = map[string]struct {
+	run         func(args []string) int
+	description string
+}{
+	"run":      {run, "Run every manager on its schedule until stopped (the default)"},
+	"once":     {once, "Run every manager once and exit"},
+	"validate": {validate, "Check the configuration without running anything"},
+	"plugins":  {plugins, "List the registered inventories, monitors, strategies, electors and notifiers, and their settings"},
+	"metrics":  {metrics, "Print the current readings of a manager's metrics"},
+	"simulate": {simulate, "Replay recorded metrics through a manager's strategy"},
+	"approve":  {approve, "Approve the scaling action a running manager is waiting for"},
+	"reject":   {reject, "Reject the scaling action a running manager is waiting for"},
+}
+
+func main() {
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		os.Exit(0)
+	}
+	command, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(command.run(args))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags each command takes.\n", filepath.Base(os.Args[0]))
+}
+
+// options are the flags shared by most commands
+type options struct {
+	*flag.FlagSet
+	config   *string
+	logLevel *string
+	managers *string
+}
+
+// newOptions creates the flags for a command. Commands that work with managers can also choose which ones to use.
+func newOptions(name string, selectManagers bool) *options {
+	o := &options{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	o.config = o.String("config", "", "Config file (default ./config/config.yaml)")
+	o.logLevel = o.String("log-level", "", "Log level, overriding logging.level in the config (debug, info, warn, error)")
+	if selectManagers {
+		o.managers = o.String("managers", "", "Comma separated managers to use (default all of them)")
 	}
-	conf.SetDefault("interval", 2*time.Minute)
+	return o
+}
+
+// setup reads the config file and sets up logging, once the flags have been parsed, exiting if the config file can't
+// be read.
+func (o *options) setup(quiet bool) *logrus.Entry {
+	if err := readConfig(*o.config); err != nil {
+		logrus.Fatalf("Can't read config file: %s", err.Error())
+	}
+	return o.setupLogging(quiet)
+}
+
+// setupLogging sets up logging once the config file has been read. Quiet commands only log errors unless --log-level
+// says otherwise, and don't send logs to any hooks.
+func (o *options) setupLogging(quiet bool) *logrus.Entry {
+	log := logrus.WithFields(logrus.Fields{})
+	if quiet {
+		logrus.SetLevel(logrus.ErrorLevel)
+	} else {
+		log = initLogger()
+	}
+	if *o.logLevel != "" {
+		level, err := logrus.ParseLevel(*o.logLevel)
+		if err != nil {
+			log.Fatalf("Invalid log level: %s", err.Error())
+		}
+		logrus.SetLevel(level)
+	}
+	return log
+}
+
+// selected returns the managers chosen with --managers, or nil for all of them
+func (o *options) selected() []string {
+	if o.managers == nil || *o.managers == "" {
+		return nil
+	}
+	return strings.Split(*o.managers, ",")
+}
+
+// newSupervisor creates a supervisor that builds managers which write to audit, defer to elector and send events to
+// notifier, loaded with the managers chosen on the command line
+func newSupervisor(o *options, audit *alice.AuditLog, elector alice.Elector, notifier alice.Notifier, log *logrus.Entry) *alice.Supervisor {
+	supervisor := alice.NewSupervisor(func(name string, config *conf.Viper) (*alice.Manager, error) {
+		mgr, err := alice.New(name, config, log.WithField("manager", name))
+		if err == nil {
+			mgr.Audit = audit
+			mgr.Elector = elector
+			mgr.Notifier = notifier
+		}
+		return mgr, err
+	}, log)
+	supervisor.Select(o.selected()...)
+	if err := supervisor.Load(conf.GetViper()); err != nil {
+		log.Fatalf("Error initializing managers: %s", err.Error())
+	}
+	return supervisor
+}
+
+// run runs managers on their schedules until alice receives SIGTERM or SIGINT
+func run(args []string) int {
+	o := newOptions("run", true)
+	o.Parse(args)
+	log := o.setup(false)
+	audit := initAuditLog(log)
+	if audit != nil {
+		defer audit.Close()
+	}
+	elector, stopElector := initElector(log)
+	defer stopElector()
+	supervisor := newSupervisor(o, audit, elector, initNotifications(log), log)
+	watchConfig(supervisor, log)
+	if conf.IsSet("http.listen") {
+		if !conf.IsSet("http.token") {
+			log.Warn("Managers can't be controlled through the status API until http.token is set")
+		}
+		server := alice.NewServer(supervisor.Managers, supervisor.Interval, conf.GetString("http.token"), log.WithField("server", "http"))
+		go func() {
+			log.Infof("Serving status API on %s", conf.GetString("http.listen"))
+			log.Fatal(http.ListenAndServe(conf.GetString("http.listen"), server))
+		}()
+	}
+
+	// Runs are only cancelled if they are still going when the shutdown timeout expires, so that scaling actions
+	// already in progress get the chance to finish.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	supervisor.Start(ctx)
+	sig := <-signals
+	done := make(chan struct{})
+	go func() {
+		supervisor.Stop()
+		close(done)
+	}()
+	shutdown(sig, done, cancel, log)
+	return 0
+}
+
+// readConfig reads the config file at path, or ./config/config.yaml if path is empty
+func readConfig(path string) error {
+	conf.SetDefault("shutdown_timeout", time.Minute)
 	conf.SetDefault("logging.level", "info")
+	if path == "" {
+		conf.AddConfigPath("./config")
+	} else {
+		conf.SetConfigFile(path)
+	}
+	return conf.ReadInConfig()
 }
 
-func main() {
-	log := initLogger()
-	var managers []alice.Manager
-	for name := range conf.GetStringMap("managers") {
-		if mgr, err := alice.New(conf.Sub("managers."+name), log.WithField("manager", name)); err != nil {
-			log.Fatalf("Error initializing manager: %s", err.Error())
-		} else {
-			managers = append(managers, mgr)
+// shutdown waits for managers that are still running to finish, cancelling them if they take longer than
+// shutdown_timeout
+func shutdown(sig os.Signal, done chan struct{}, cancel context.CancelFunc, log *logrus.Entry) {
+	timeout := conf.GetDuration("shutdown_timeout")
+	log.Infof("Received %v, waiting up to %v for managers to finish", sig, timeout)
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warn("Managers took too long to finish, cancelling them")
+		cancel()
+		<-done
+	}
+	log.Info("Shut down")
+}
+
+// watchConfig reloads the configuration whenever the config file changes or alice receives SIGHUP
+func watchConfig(supervisor *alice.Supervisor, log *logrus.Entry) {
+	watcher := conf.New()
+	watcher.SetConfigFile(conf.ConfigFileUsed())
+	watcher.OnConfigChange(func(fsnotify.Event) { reloadConfig(supervisor, log) })
+	watcher.WatchConfig()
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			reloadConfig(supervisor, log)
 		}
+	}()
+}
+
+// reloadConfig reads the config file again and applies any changes to the managers. If the new configuration can't
+// be read, or has any of the problems alice validate finds, the current configuration stays in use.
+func reloadConfig(supervisor *alice.Supervisor, log *logrus.Entry) {
+	log.Info("Reloading configuration")
+	next := conf.New()
+	next.SetConfigFile(conf.ConfigFileUsed())
+	if err := next.ReadInConfig(); err != nil {
+		log.Errorf("Keeping current configuration as the new one can't be read: %s", err.Error())
+		return
 	}
-	for {
-		var wg sync.WaitGroup
-		wg.Add(len(managers))
-		for _, man := range managers {
-			go func(m alice.Manager) {
-				defer wg.Done()
-				m.Run()
-			}(man)
+	if errs := alice.Validate(next, conf.ConfigFileUsed(), log.WithField("command", "reload")); len(errs) > 0 {
+		for _, err := range errs {
+			log.Errorf("Keeping current configuration as the new one is invalid: %s", err.Error())
 		}
-		wg.Wait()
-		time.Sleep(conf.GetDuration("interval"))
+		return
+	}
+	if err := supervisor.Load(next); err != nil {
+		log.Errorf("Keeping current configuration as the new one is invalid: %s", err.Error())
+		return
+	}
+	if err := conf.ReadInConfig(); err != nil {
+		log.Errorf("Error reloading global configuration: %s", err.Error())
+	}
+}
+
+// initElector starts campaigning for leadership if leader_election is configured. The returned function gives up
+// leadership and waits for the elector to stop.
+func initElector(log *logrus.Entry) (alice.Elector, func()) {
+	if !conf.IsSet("leader_election") {
+		return nil, func() {}
+	}
+	elector, err := alice.NewElector(conf.Sub("leader_election"), log)
+	if err != nil {
+		log.Fatalf("Error initializing leader election: %s", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := elector.Run(ctx); err != nil && err != context.Canceled {
+			log.Errorf("Leader election stopped: %s", err.Error())
+		}
+	}()
+	return elector, func() {
+		cancel()
+		<-done
+	}
+}
+
+// initNotifications creates the notifiers configured under notifiers, if there are any
+func initNotifications(log *logrus.Entry) alice.Notifier {
+	if !conf.IsSet("notifiers") {
+		return nil
+	}
+	notifications, err := alice.NewNotifications(conf.Sub("notifiers"), log)
+	if err != nil {
+		log.Fatalf("Error initializing notifiers: %s", err.Error())
+	}
+	return notifications
+}
+
+func initAuditLog(log *logrus.Entry) *alice.AuditLog {
+	if !conf.IsSet("audit") {
+		return nil
+	}
+	auditConf := conf.Sub("audit")
+	auditConf.SetDefault("max_size_mb", 100)
+	auditConf.SetDefault("max_backups", 5)
+	if !auditConf.IsSet("path") {
+		log.Fatalln("Must provide path for the audit log.")
+	}
+	audit, err := alice.NewAuditLog(auditConf.GetString("path"), int64(auditConf.GetInt("max_size_mb"))*1024*1024, auditConf.GetInt("max_backups"))
+	if err != nil {
+		log.Fatalf("Error opening audit log: %s", err.Error())
 	}
+	return audit
 }
 
 func initLogger() *logrus.Entry {
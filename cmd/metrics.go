@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/notonthehighstreet/alice"
+	conf "github.com/spf13/viper"
+)
+
+// metrics prints the current readings of a manager's metrics from its monitor, without evaluating its strategy or
+// touching its inventory. The metrics are the remaining arguments, or by default the ones the strategy uses. It
+// returns the exit code.
+func metrics(args []string) int {
+	o := newOptions("metrics", false)
+	o.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: alice metrics [flags] <manager> [metric...]")
+		o.PrintDefaults()
+	}
+	o.Parse(args)
+	log := o.setup(true)
+	if o.NArg() < 1 {
+		o.Usage()
+		return 2
+	}
+	name, names := o.Arg(0), o.Args()[1:]
+	managerConf := conf.Sub("managers." + name)
+	if managerConf == nil {
+		fmt.Fprintf(os.Stderr, "No manager called %s\n", name)
+		return 1
+	}
+	mgr, err := alice.New(name, managerConf, log.WithField("manager", name))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing manager %s: %s\n", name, err.Error())
+		return 1
+	}
+	if len(names) == 0 {
+		str, ok := mgr.Strategy.(alice.MetricStrategy)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "The %s strategy doesn't say which metrics it uses, so name them\n", managerConf.GetString("strategy.name"))
+			return 1
+		}
+		names = str.Metrics()
+	}
+	updates, err := mgr.Monitor.GetUpdatedMetrics(context.Background(), names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting metrics: %s\n", err.Error())
+		return 1
+	}
+	for _, update := range *updates {
+		fmt.Printf("%s %s\n", update.Name, strconv.FormatFloat(update.CurrentReading, 'f', -1, 64))
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/notonthehighstreet/alice"
+	conf "github.com/spf13/viper"
+)
+
+// follower is an elector that never leads, for one-off runs alongside copies of alice that take part in leader
+// election
+type follower struct{}
+
+func (follower) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (follower) IsLeader() bool {
+	return false
+}
+
+// once runs every selected manager a single time, all at the same time, and returns the exit code, which is 1 if any
+// of them failed. With leader_election configured, other copies of alice may be scaling the same inventories, so the
+// managers only log what they would have done unless --act is given.
+func once(args []string) int {
+	o := newOptions("once", true)
+	act := o.Bool("act", false, "Scale even though leader_election is configured, as if this were the leader")
+	o.Parse(args)
+	log := o.setup(false)
+	audit := initAuditLog(log)
+	if audit != nil {
+		defer audit.Close()
+	}
+	var elector alice.Elector
+	if conf.IsSet("leader_election") && !*act {
+		log.Warn("leader_election is configured, so not scaling anything without --act")
+		elector = follower{}
+	}
+	supervisor := newSupervisor(o, audit, elector, initNotifications(log), log)
+
+	var wg sync.WaitGroup
+	managers := supervisor.Managers()
+	errs := make([]error, len(managers))
+	for i, mgr := range managers {
+		wg.Add(1)
+		go func(i int, mgr *alice.Manager) {
+			defer wg.Done()
+			errs[i] = mgr.Run(context.Background())
+		}(i, mgr)
+	}
+	wg.Wait()
+	code := 0
+	for i, err := range errs {
+		if err != nil {
+			log.Errorf("Manager %s failed: %s", managers[i].Name, err.Error())
+			code = 1
+		}
+	}
+	return code
+}
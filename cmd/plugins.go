@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/notonthehighstreet/alice"
+)
+
+// plugins lists the names of the registered plugins of each kind, which can be used as the 'name' of a plugin in the
+// configuration. With --docs it describes the settings each plugin takes, and with --schema it prints a JSON Schema
+// for the config file instead. It returns the exit code.
+func plugins(args []string) int {
+	flags := flag.NewFlagSet("plugins", flag.ExitOnError)
+	docs := flags.Bool("docs", false, "Describe the settings each plugin takes")
+	schema := flags.Bool("schema", false, "Print a JSON Schema for the config file, for editors to check it against")
+	flags.Parse(args)
+
+	if *schema {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if err := out.Encode(alice.ConfigFileSchema()); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		return 0
+	}
+	for _, kind := range []struct {
+		title string
+		kind  string
+		names []string
+	}{
+		{"Inventories", alice.InventoryPlugin, alice.Inventories()},
+		{"Monitors", alice.MonitorPlugin, alice.Monitors()},
+		{"Strategies", alice.StrategyPlugin, alice.Strategies()},
+		{"Electors", alice.ElectorPlugin, alice.Electors()},
+		{"Notifiers", alice.NotifierPlugin, alice.Notifiers()},
+	} {
+		if !*docs {
+			fmt.Printf("%s: %s\n", kind.title, strings.Join(kind.names, ", "))
+			continue
+		}
+		fmt.Printf("# %s\n\n", kind.title)
+		for _, name := range kind.names {
+			describePlugin(kind.kind, name)
+		}
+	}
+	return 0
+}
+
+// describePlugin prints the settings a plugin takes as Markdown
+func describePlugin(kind, name string) {
+	fmt.Printf("## %s\n\n", name)
+	spec := alice.PluginConfig(kind, name)
+	if spec == nil {
+		fmt.Print("No settings described.\n\n")
+		return
+	}
+	fmt.Println("| Key | Type | Default | Description |")
+	fmt.Println("| --- | --- | --- | --- |")
+	for _, field := range alice.ConfigFields(spec) {
+		def := field.Default
+		if field.Required {
+			def = "required"
+		}
+		fmt.Printf("| %s | %s | %s | %s |\n", field.Key, field.Type, def, field.Description)
+	}
+	fmt.Println()
+}
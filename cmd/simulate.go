@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	conf "github.com/spf13/viper"
+)
+
+// simulate replays recorded metrics through a manager's strategy, writing the size of the inventory at each run as
+// CSV to stdout and a summary to stderr. It returns the exit code.
+func simulate(args []string) int {
+	flags := newOptions("simulate", false)
+	name := flags.String("manager", "", "Manager to simulate, required if more than one is configured")
+	metricsPath := flags.String("metrics", "", "Recorded metrics, as CSV or JSON lines (.json or .jsonl)")
+	initialTotal := flags.Int("initial-total", 1, "Size of the inventory at the start")
+	provisionDelay := flags.Duration("provision-delay", 0, "How long new resources take to become ready")
+	perResource := flags.String("per-resource", "", "Comma separated metrics that measure load per resource")
+	interval := flags.Duration("interval", 0, "How often the manager runs (default the manager's interval)")
+	verbose := flags.Bool("verbose", false, "Log what the manager does")
+	flags.Parse(args)
+
+	fail := func(format string, args ...interface{}) int {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		return 1
+	}
+	if *metricsPath == "" {
+		return fail("Must provide --metrics")
+	}
+	if *verbose && *flags.logLevel == "" {
+		*flags.logLevel = "info"
+	}
+	log := flags.setup(true)
+	if *name == "" {
+		managers := conf.GetStringMap("managers")
+		if len(managers) != 1 {
+			return fail("Must choose a manager with --manager")
+		}
+		for n := range managers {
+			*name = n
+		}
+	}
+	managerConf := conf.Sub("managers." + *name)
+	if managerConf == nil {
+		return fail("No manager called %s", *name)
+	}
+	if !managerConf.IsSet("interval") && conf.IsSet("interval") {
+		managerConf.Set("interval", conf.GetDuration("interval"))
+	}
+
+	samples, err := readSamples(*metricsPath)
+	if err != nil {
+		return fail("Error reading %s: %s", *metricsPath, err.Error())
+	}
+	sim, err := alice.NewSimulation(*name, managerConf, log.WithField("manager", *name))
+	if err != nil {
+		return fail("Error initializing manager %s: %s", *name, err.Error())
+	}
+	sim.InitialTotal, sim.ProvisionDelay = *initialTotal, *provisionDelay
+	if *perResource != "" {
+		sim.PerResource = strings.Split(*perResource, ",")
+	}
+	if *interval != 0 {
+		sim.Interval = *interval
+	}
+	result, err := sim.Run(context.Background(), samples)
+	if err != nil {
+		return fail("Error simulating %s: %s", *name, err.Error())
+	}
+
+	out := csv.NewWriter(os.Stdout)
+	var metrics []string
+	if len(samples) > 0 {
+		for metric := range samples[0].Metrics {
+			metrics = append(metrics, metric)
+		}
+		sort.Strings(metrics)
+	}
+	out.Write(append([]string{"time", "recommendation", "action", "total", "ready"}, metrics...))
+	for _, step := range result.Steps {
+		rec := ""
+		if step.Recommendation != nil {
+			rec = strconv.Itoa(int(*step.Recommendation))
+		}
+		row := []string{step.Time.Format(time.RFC3339), rec, string(step.Action), strconv.Itoa(step.Total), strconv.Itoa(step.Ready)}
+		readings := map[string]float64{}
+		for _, m := range step.Metrics {
+			readings[m.Name] = m.CurrentReading
+		}
+		for _, metric := range metrics {
+			reading, ok := readings[metric]
+			if ok {
+				row = append(row, strconv.FormatFloat(reading, 'f', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	fmt.Fprintf(os.Stderr, "%d runs, %d scale ups, %d scale downs, inventory between %d and %d\n",
+		len(result.Steps), result.ScaleUps, result.ScaleDowns, result.MinTotal, result.MaxTotal)
+	return 0
+}
+
+// readSamples reads recorded metrics from a CSV file, or from JSON lines if the file ends in .json or .jsonl
+func readSamples(path string) ([]alice.Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch filepath.Ext(path) {
+	case ".json", ".jsonl":
+		return alice.ReadSamplesJSON(f)
+	default:
+		return alice.ReadSamplesCSV(f)
+	}
+}
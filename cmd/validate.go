@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/notonthehighstreet/alice"
+	conf "github.com/spf13/viper"
+)
+
+// validate checks the configuration of every manager without running them, printing every problem found. It returns
+// the exit code, which is 1 if there were any problems.
+func validate(args []string) int {
+	o := newOptions("validate", false)
+	o.Parse(args)
+	if err := readConfig(*o.config); err != nil {
+		file := *o.config
+		if file == "" {
+			file = "./config/config.yaml"
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err.Error())
+		return 1
+	}
+	log := o.setupLogging(true)
+	errs := alice.Validate(conf.GetViper(), conf.ConfigFileUsed(), log.WithField("command", "validate"))
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(errs))
+		return 1
+	}
+	fmt.Printf("%s is valid\n", conf.ConfigFileUsed())
+	return 0
+}
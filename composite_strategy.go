@@ -0,0 +1,252 @@
+package alice
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Policies for combining the recommendations of a CompositeStrategy's strategies
+const (
+	// PolicyWorstCase - the recommendation that adds the most capacity wins. Any strategy failing fails the evaluation.
+	PolicyWorstCase = "worst_case"
+	// PolicyUnanimousDown - scale up if any strategy recommends it, by the largest step, and only scale down if every
+	// strategy recommends it, by the smallest step. A strategy that fails counts as not agreeing to scale down.
+	PolicyUnanimousDown = "unanimous_down"
+	// PolicyMajority - scale in a direction if more than half the strategies recommend it, by the smallest step any of
+	// them recommend, otherwise hold. A strategy that fails counts as recommending holding.
+	PolicyMajority = "majority"
+	// PolicyPriority - the first strategy in priority order that doesn't recommend holding wins. Strategies that fail
+	// are skipped.
+	PolicyPriority = "priority"
+)
+
+// StrategyRecommendation is what one of several strategies recommended
+type StrategyRecommendation struct {
+	Name           string          `json:"name"`
+	Strategy       string          `json:"strategy"`
+	Recommendation *Recommendation `json:"recommendation"`
+	Evaluation     *Evaluation     `json:"evaluation,omitempty"` // If the strategy explains itself
+	Error          string          `json:"error,omitempty"`
+}
+
+// CompositeStrategy evaluates several strategies, each with its own block of configuration under 'strategies', and
+// combines their recommendations according to its 'policy'
+type CompositeStrategy struct {
+	Config     *viper.Viper
+	Strategies map[string]Strategy
+	log        *logrus.Entry
+	mu         sync.Mutex
+	last       []StrategyRecommendation
+	lastEvaluation
+}
+
+// CompositeConfig is the configuration of a CompositeStrategy
+type CompositeConfig struct {
+	Policy     string                          `config:"policy" default:"worst_case" description:"How to combine the recommendations: worst_case, unanimous_down, majority or priority"`
+	Priority   []string                        `config:"priority" description:"Names of the strategies, most important first, for the priority policy"`
+	Strategies map[string]CompositeChildConfig `config:"strategies" required:"true" description:"The strategies to combine, by a name of your choosing, each configured as usual"`
+}
+
+// CompositeChildConfig is the configuration of one of a CompositeStrategy's strategies. The rest of its settings
+// depend on the strategy.
+type CompositeChildConfig struct {
+	Name string `config:"name" required:"true" description:"Which strategy to use"`
+}
+
+// NewCompositeStrategy creates a new Strategy
+func NewCompositeStrategy(config *viper.Viper, inv Inventory, mon Monitor, log *logrus.Entry) (Strategy, error) {
+	var c CompositeConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	strategies := map[string]Strategy{}
+	for name := range c.Strategies {
+		str, err := NewStrategy(config.Sub("strategies."+name), inv, mon, log.WithField("child", name))
+		if err != nil {
+			return nil, ConfigErrors(inConfig("strategies."+name, err))
+		}
+		strategies[name] = str
+	}
+	return &CompositeStrategy{Config: config, Strategies: strategies, log: log}, nil
+}
+
+// Validate checks the policy and priority order, and asks each strategy to validate itself
+func (c *CompositeStrategy) Validate() []error {
+	var errs []error
+	switch policy := c.Config.GetString("policy"); policy {
+	case PolicyWorstCase, PolicyUnanimousDown, PolicyMajority:
+	case PolicyPriority:
+		if len(c.Config.GetStringSlice("priority")) == 0 {
+			errs = append(errs, configErrorf("priority", "Must list the strategies in priority order"))
+		}
+	default:
+		errs = append(errs, configErrorf("policy", "Unknown policy: %s", policy))
+	}
+	priority := map[string]bool{}
+	for _, name := range c.Config.GetStringSlice("priority") {
+		priority[name] = true
+		if _, ok := c.Strategies[name]; !ok {
+			errs = append(errs, configErrorf("priority", "No strategy called %s", name))
+		}
+	}
+	for _, name := range c.names() {
+		if c.Config.GetString("policy") == PolicyPriority && !priority[name] {
+			errs = append(errs, configErrorf("priority", "Must include %s", name))
+		}
+		if v, ok := c.Strategies[name].(Validator); ok {
+			errs = append(errs, inConfig("strategies."+name, v.Validate()...)...)
+		}
+	}
+	return errs
+}
+
+// Metrics returns the names of the metrics any of the strategies use, sorted
+func (c *CompositeStrategy) Metrics() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, str := range c.Strategies {
+		if m, ok := str.(MetricStrategy); ok {
+			for _, name := range m.Metrics() {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Recommendations returns what each strategy recommended the last time the composite strategy was evaluated
+func (c *CompositeStrategy) Recommendations() []StrategyRecommendation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// Evaluate asks every strategy for a recommendation, and combines them according to the policy. The explanation of
+// the first strategy to recommend the combined recommendation is kept for Explain.
+func (c *CompositeStrategy) Evaluate(ctx context.Context) (*Recommendation, error) {
+	c.remember(nil)
+	policy := c.Config.GetString("policy")
+	names := c.names()
+	if policy == PolicyPriority {
+		names = c.Config.GetStringSlice("priority")
+	}
+	results := make([]StrategyRecommendation, 0, len(names))
+	var recs []Recommendation
+	failures := 0
+	for _, name := range names {
+		str, ok := c.Strategies[name]
+		if !ok {
+			return nil, errors.Errorf("No strategy called %s", name)
+		}
+		result := StrategyRecommendation{Name: name, Strategy: c.Config.GetString("strategies." + name + ".name")}
+		rec, err := str.Evaluate(ctx)
+		if err != nil {
+			c.log.Warnf("Strategy %s failed: %s", name, err.Error())
+			result.Error = err.Error()
+			failures++
+		} else {
+			c.log.Infof("Strategy %s recommends %v", name, *rec)
+			result.Recommendation = rec
+			recs = append(recs, *rec)
+			if str, ok := str.(ExplainedStrategy); ok {
+				result.Evaluation = str.Explain()
+			}
+		}
+		results = append(results, result)
+	}
+	c.mu.Lock()
+	c.last = results
+	c.mu.Unlock()
+
+	if len(recs) == 0 {
+		return nil, errors.New("Every strategy failed to make a recommendation")
+	}
+	var final Recommendation
+	switch policy {
+	case PolicyWorstCase:
+		if failures > 0 {
+			return nil, errors.Errorf("%d of %d strategies failed to make a recommendation", failures, len(names))
+		}
+		final = worstCase(recs)
+	case PolicyUnanimousDown:
+		final = worstCase(recs)
+		if final < HOLD && failures > 0 {
+			final = HOLD
+		}
+	case PolicyMajority:
+		final = majority(recs, len(names))
+	case PolicyPriority:
+		final = HOLD
+		for _, rec := range recs {
+			if rec != HOLD {
+				final = rec
+				break
+			}
+		}
+	default:
+		return nil, errors.Errorf("Unknown policy: %s", policy)
+	}
+	c.log.Debugf("Recommending %v using the %s policy", final, policy)
+	for _, result := range results {
+		if result.Recommendation != nil && *result.Recommendation == final && result.Evaluation != nil {
+			evaluation := *result.Evaluation
+			c.remember(&evaluation)
+			break
+		}
+	}
+	return &final, nil
+}
+
+// names returns the names of the strategies, sorted
+func (c *CompositeStrategy) names() []string {
+	names := make([]string, 0, len(c.Strategies))
+	for name := range c.Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// worstCase returns the recommendation that adds the most capacity
+func worstCase(recs []Recommendation) Recommendation {
+	worst := recs[0]
+	for _, rec := range recs[1:] {
+		if rec > worst {
+			worst = rec
+		}
+	}
+	return worst
+}
+
+// majority returns the direction more than half of voters recommend, by the smallest step any of them recommend, or
+// HOLD if there is no majority. Voters who didn't make a recommendation count as holding.
+func majority(recs []Recommendation, voters int) Recommendation {
+	for _, up := range []bool{true, false} {
+		var agreed []Recommendation
+		for _, rec := range recs {
+			if (up && rec > HOLD) || (!up && rec < HOLD) {
+				agreed = append(agreed, rec)
+			}
+		}
+		if len(agreed)*2 <= voters {
+			continue
+		}
+		smallest := agreed[0]
+		for _, rec := range agreed[1:] {
+			if rec.Step() < smallest.Step() {
+				smallest = rec
+			}
+		}
+		return smallest
+	}
+	return HOLD
+}
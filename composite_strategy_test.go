@@ -0,0 +1,159 @@
+package alice_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+var compositeStrategy *alice.CompositeStrategy
+
+func setupCompositeStrategyTest(policy string) {
+	config = viper.New()
+	config.Set("policy", policy)
+	config.Set("strategies", map[string]interface{}{
+		"cpu":    map[string]interface{}{"name": "mock"},
+		"memory": map[string]interface{}{"name": "mock"},
+		"users":  map[string]interface{}{"name": "mock"},
+	})
+	config.Set("priority", []string{"users", "cpu", "memory"})
+	s, err := alice.NewCompositeStrategy(config, &MockInventory{}, &MockMonitor{}, log)
+	if err != nil {
+		panic(err)
+	}
+	compositeStrategy = s.(*alice.CompositeStrategy)
+}
+
+// recommend sets up what each of the composite strategy's strategies recommends. A nil recommendation fails.
+func recommend(recs map[string]*alice.Recommendation) {
+	for name, rec := range recs {
+		str := compositeStrategy.Strategies[name].(*MockStrategy)
+		str.ExpectedCalls = nil
+		if rec == nil {
+			str.On("Evaluate").Return(rec, errors.New("No data"))
+		} else {
+			str.On("Evaluate").Return(rec, nil)
+		}
+	}
+}
+
+func rec(r alice.Recommendation) *alice.Recommendation {
+	return &r
+}
+
+func evaluateComposite(t *testing.T) alice.Recommendation {
+	recommendation, err := compositeStrategy.Evaluate(ctx)
+	assert.NoError(t, err)
+	if recommendation == nil {
+		return alice.HOLD
+	}
+	return *recommendation
+}
+
+func TestCompositeStrategy_WorstCase(t *testing.T) {
+	setupCompositeStrategyTest("worst_case")
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": rec(2), "users": rec(1)})
+	assert.Equal(t, alice.Recommendation(2), evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": rec(-3), "users": rec(-2)})
+	assert.Equal(t, alice.Recommendation(-1), evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": rec(0), "users": rec(-2)})
+	assert.Equal(t, alice.HOLD, evaluateComposite(t))
+
+	recommend(map[string]*alice.Recommendation{"cpu": rec(1), "memory": nil, "users": rec(1)})
+	_, err := compositeStrategy.Evaluate(ctx)
+	assert.Error(t, err)
+}
+
+func TestCompositeStrategy_UnanimousDown(t *testing.T) {
+	setupCompositeStrategyTest("unanimous_down")
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": rec(-2), "users": rec(-1)})
+	assert.Equal(t, alice.SCALEDOWN, evaluateComposite(t))
+
+	// A failed strategy doesn't stop scaling up, but does stop scaling down
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": nil, "users": rec(-1)})
+	assert.Equal(t, alice.HOLD, evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-1), "memory": nil, "users": rec(3)})
+	assert.Equal(t, alice.Recommendation(3), evaluateComposite(t))
+
+	recommend(map[string]*alice.Recommendation{"cpu": nil, "memory": nil, "users": nil})
+	_, err := compositeStrategy.Evaluate(ctx)
+	assert.Error(t, err)
+}
+
+func TestCompositeStrategy_Majority(t *testing.T) {
+	setupCompositeStrategyTest("majority")
+	recommend(map[string]*alice.Recommendation{"cpu": rec(3), "memory": rec(2), "users": rec(-1)})
+	assert.Equal(t, alice.Recommendation(2), evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(-2), "memory": rec(-4), "users": rec(1)})
+	assert.Equal(t, alice.Recommendation(-2), evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(1), "memory": rec(0), "users": rec(-1)})
+	assert.Equal(t, alice.HOLD, evaluateComposite(t))
+
+	// A failed strategy counts as holding
+	recommend(map[string]*alice.Recommendation{"cpu": rec(1), "memory": nil, "users": rec(0)})
+	assert.Equal(t, alice.HOLD, evaluateComposite(t))
+}
+
+func TestCompositeStrategy_Priority(t *testing.T) {
+	setupCompositeStrategyTest("priority")
+	recommend(map[string]*alice.Recommendation{"cpu": rec(2), "memory": rec(1), "users": rec(-1)})
+	assert.Equal(t, alice.SCALEDOWN, evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": rec(2), "memory": rec(1), "users": rec(0)})
+	assert.Equal(t, alice.Recommendation(2), evaluateComposite(t))
+	recommend(map[string]*alice.Recommendation{"cpu": nil, "memory": rec(1), "users": rec(0)})
+	assert.Equal(t, alice.SCALEUP, evaluateComposite(t))
+
+	recs := compositeStrategy.Recommendations()
+	assert.Len(t, recs, 3)
+	assert.Equal(t, alice.StrategyRecommendation{Name: "users", Strategy: "mock", Recommendation: rec(0)}, recs[0])
+	assert.Equal(t, "No data", recs[1].Error)
+	assert.Nil(t, recs[1].Recommendation)
+}
+
+func TestCompositeStrategy_Validate(t *testing.T) {
+	setupCompositeStrategyTest("priority")
+	assert.Empty(t, compositeStrategy.Validate())
+	config.Set("priority", []string{"users", "disk"})
+	assert.Len(t, compositeStrategy.Validate(), 3)
+	config.Set("policy", "random")
+	assert.Len(t, compositeStrategy.Validate(), 2)
+
+	config = viper.New()
+	config.Set("strategies", map[string]interface{}{"cpu": map[string]interface{}{"name": "unknown"}})
+	_, err := alice.NewCompositeStrategy(config, &MockInventory{}, &MockMonitor{}, log)
+	assert.Error(t, err)
+}
+
+func TestManager_RunComposite(t *testing.T) {
+	setupCompositeStrategyTest("worst_case")
+	recommend(map[string]*alice.Recommendation{"cpu": rec(0), "memory": rec(0), "users": rec(0)})
+	inv := MockInventory{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	mgr := alice.Manager{Name: "web", Strategy: compositeStrategy, Inventory: &inv, Logger: log, Config: viper.New()}
+	assert.NoError(t, mgr.Run(ctx))
+	assert.Len(t, mgr.Status().Strategies, 3)
+	assert.Equal(t, "cpu", mgr.Status().Strategies[0].Name)
+}
+
+func TestCompositeStrategy_Explain(t *testing.T) {
+	setupCompositeStrategyTest("worst_case")
+	setupThresholdStrategyTest()
+	config.Set("thresholds.cpu.max", 80)
+	mockResponse = []alice.MetricUpdate{{Name: "cpu", CurrentReading: 90}}
+	compositeStrategy.Strategies["cpu"] = thresholdStrategy
+	recommend(map[string]*alice.Recommendation{"memory": rec(0), "users": rec(-1)})
+	assert.Equal(t, alice.SCALEUP, evaluateComposite(t))
+	assert.Equal(t, "cpu is 90, above the max of 80", compositeStrategy.Explain().String())
+	assert.NotNil(t, compositeStrategy.Recommendations()[0].Evaluation)
+	assert.Nil(t, compositeStrategy.Recommendations()[1].Evaluation)
+
+	// Strategies that don't explain themselves leave nothing to explain
+	mockResponse = []alice.MetricUpdate{{Name: "cpu", CurrentReading: 50}}
+	recommend(map[string]*alice.Recommendation{"memory": rec(2), "users": rec(-1)})
+	assert.Equal(t, alice.Recommendation(2), evaluateComposite(t))
+	assert.Nil(t, compositeStrategy.Explain())
+}
@@ -0,0 +1,53 @@
+package alice
+
+import "time"
+
+// breach tracks how long a strategy has been recommending scaling in the same direction, so that a Manager can wait
+// for a recommendation to persist before acting on it.
+type breach struct {
+	direction   Recommendation
+	since       time.Time
+	evaluations int
+}
+
+// observe records the direction of a recommendation made at the given time. A change of direction starts a new breach.
+func (b *breach) observe(rec Recommendation, now time.Time) {
+	direction := HOLD
+	switch {
+	case rec > HOLD:
+		direction = SCALEUP
+	case rec < HOLD:
+		direction = SCALEDOWN
+	}
+	if b.evaluations == 0 || direction != b.direction {
+		b.direction = direction
+		b.since = now
+		b.evaluations = 0
+	}
+	b.evaluations++
+}
+
+// reset forgets the current breach, eg when a strategy fails to give a recommendation.
+func (b *breach) reset() {
+	b.evaluations = 0
+}
+
+// confirmed records the recommendation and returns true once it has persisted for the number of evaluations and the
+// length of time configured for its direction (scale_up_confirmations and scale_up_after, or scale_down_confirmations
+// and scale_down_after). With nothing configured every recommendation is confirmed straight away.
+func (m *Manager) confirmed(rec Recommendation) bool {
+	now := m.now()
+	m.breach.observe(rec, now)
+	if rec == HOLD {
+		return true
+	}
+	direction := "scale_" + rec.direction()
+	evaluations := m.Config.GetInt(direction + "_confirmations")
+	after := m.Config.GetDuration(direction + "_after")
+	persisted := now.Sub(m.breach.since)
+	if m.breach.evaluations < evaluations || persisted < after {
+		m.Logger.Infof("Waiting for %s recommendation to persist. Seen %d times over %v, need %d times over %v", direction, m.breach.evaluations, persisted, evaluations, after)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_RunConfirmsByEvaluations(t *testing.T) {
+	config := viper.New()
+	config.Set("scale_up_confirmations", 3)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man := alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	up, hold := alice.SCALEUP, alice.HOLD
+
+	str.On("Evaluate").Return(&up, nil).Twice()
+	man.Run(ctx)
+	man.Run(ctx)
+	inv.AssertNotCalled(t, "Increase", 1)
+
+	str.On("Evaluate").Return(&hold, nil).Once()
+	man.Run(ctx)
+	str.On("Evaluate").Return(&up, nil).Twice()
+	man.Run(ctx)
+	man.Run(ctx)
+	inv.AssertNotCalled(t, "Increase", 1)
+
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+
+	// A breach that carries on after scaling has to be confirmed again before scaling again
+	str.On("Evaluate").Return(&up, nil).Times(3)
+	man.Run(ctx)
+	man.Run(ctx)
+	assert.Equal(t, alice.ActionUnconfirmed, man.Status().Action)
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionScaled, man.Status().Action)
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunConfirmsByDuration(t *testing.T) {
+	now := time.Date(2017, 2, 1, 12, 0, 0, 0, time.UTC)
+	config := viper.New()
+	config.Set("scale_up_after", "3m")
+	config.Set("scale_down_after", "15m")
+	inv := MockInventory{}
+	str := MockStrategy{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man := alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config, Clock: func() time.Time { return now }}
+	up, down := alice.SCALEUP, alice.SCALEDOWN
+
+	str.On("Evaluate").Return(&up, nil).Times(3)
+	man.Run(ctx)
+	now = now.Add(2 * time.Minute)
+	man.Run(ctx)
+	inv.AssertNotCalled(t, "Increase", 1)
+	now = now.Add(time.Minute)
+	inv.On("Increase", 1).Return(nil).Once()
+	man.Run(ctx)
+	inv.AssertExpectations(t)
+
+	// The time a breach has lasted starts again after scaling
+	str.On("Evaluate").Return(&up, nil).Twice()
+	now = now.Add(time.Minute)
+	man.Run(ctx)
+	now = now.Add(2 * time.Minute)
+	man.Run(ctx)
+	assert.Equal(t, alice.ActionUnconfirmed, man.Status().Action)
+	inv.AssertNumberOfCalls(t, "Increase", 1)
+
+	str.On("Evaluate").Return(&down, nil).Times(2)
+	man.Run(ctx)
+	now = now.Add(10 * time.Minute)
+	man.Run(ctx)
+	inv.AssertNotCalled(t, "Decrease", 1)
+	now = now.Add(5 * time.Minute)
+	str.On("Evaluate").Return(&down, nil).Once()
+	inv.On("Decrease", 1).Return(nil).Once()
+	man.Run(ctx)
+	inv.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package alice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	consul "github.com/hashicorp/consul/api"
+	"github.com/spf13/viper"
+)
+
+// ConsulLocker allows mocks of a consul lock
+type ConsulLocker interface {
+	Lock(stopCh <-chan struct{}) (<-chan struct{}, error)
+	Unlock() error
+}
+
+// ConsulElector holds leadership for as long as it holds a lock on a key in Consul. The lock is tied to a Consul
+// session, so if this instance dies the session expires after its TTL and another instance takes over.
+type ConsulElector struct {
+	log    *logrus.Entry
+	config *viper.Viper
+	Lock   ConsulLocker
+	mu     sync.RWMutex
+	leader bool
+}
+
+// ConsulConfig is the configuration of a ConsulElector
+type ConsulConfig struct {
+	Address       string        `config:"address" description:"Address of the Consul agent (default the local agent)"`
+	Token         string        `config:"token" description:"Consul ACL token"`
+	Key           string        `config:"key" default:"service/alice/leader" description:"Key to lock, the same for every copy of alice"`
+	SessionTTL    string        `config:"session_ttl" default:"15s" description:"How long after the leader dies before another copy can take over"`
+	RetryInterval time.Duration `config:"retry_interval" default:"10s" description:"How long to wait before trying to take the lock again after an error"`
+}
+
+// NewConsulElector creates a new Elector
+func NewConsulElector(config *viper.Viper, log *logrus.Entry) (Elector, error) {
+	var c ConsulConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	consulConfig := consul.DefaultConfig()
+	if c.Address != "" {
+		consulConfig.Address = c.Address
+	}
+	if c.Token != "" {
+		consulConfig.Token = c.Token
+	}
+	client, err := consul.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := client.LockOpts(&consul.LockOptions{
+		Key:         c.Key,
+		SessionName: "alice",
+		SessionTTL:  c.SessionTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ConsulElector{log: log, config: config, Lock: lock}, nil
+}
+
+// Run campaigns for leadership until the context is cancelled, giving up leadership on the way out
+func (c *ConsulElector) Run(ctx context.Context) error {
+	for {
+		lost, err := c.Lock.Lock(ctx.Done())
+		if err != nil {
+			c.log.Errorf("Can't acquire leadership lock: %s", err.Error())
+			select {
+			case <-time.After(c.config.GetDuration("retry_interval")):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lost == nil {
+			// Lock gave up because we are stopping
+			return ctx.Err()
+		}
+		c.log.Warn("Became the leader")
+		c.setLeader(true)
+		select {
+		case <-lost:
+			c.log.Warn("Lost leadership")
+			c.setLeader(false)
+			// The lock still counts itself as held once lost, and won't lock again until it is released
+			if err := c.Lock.Unlock(); err != nil && err != consul.ErrLockNotHeld {
+				c.log.Errorf("Can't release the lost leadership lock: %s", err.Error())
+			}
+		case <-ctx.Done():
+			c.setLeader(false)
+			c.log.Info("Giving up leadership")
+			return c.Lock.Unlock()
+		}
+	}
+}
+
+// IsLeader returns true while this instance holds the lock
+func (c *ConsulElector) IsLeader() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.leader
+}
+
+func (c *ConsulElector) setLeader(leader bool) {
+	c.mu.Lock()
+	c.leader = leader
+	c.mu.Unlock()
+}
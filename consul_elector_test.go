@@ -0,0 +1,68 @@
+package alice_test
+
+import (
+	"context"
+	"testing"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockConsulLocker behaves like a consul lock in refusing to lock again until it has been unlocked, even if the lock
+// has been lost in the meantime
+type MockConsulLocker struct {
+	mock.Mock
+	held bool
+}
+
+func (m *MockConsulLocker) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	if m.held {
+		return nil, consul.ErrLockHeld
+	}
+	args := m.Called()
+	m.held = args.Error(1) == nil
+	return args.Get(0).(chan struct{}), args.Error(1)
+}
+
+func (m *MockConsulLocker) Unlock() error {
+	if !m.held {
+		return consul.ErrLockNotHeld
+	}
+	m.held = false
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestConsulElector_Run(t *testing.T) {
+	config := viper.New()
+	config.Set("address", "127.0.0.1:8500")
+	e, err := alice.NewConsulElector(config, log)
+	assert.NoError(t, err)
+	elector := e.(*alice.ConsulElector)
+	locker := &MockConsulLocker{}
+	elector.Lock = locker
+
+	firstLost, secondLost := make(chan struct{}), make(chan struct{})
+	locker.On("Lock").Return(firstLost, nil).Once()
+	relocked := make(chan struct{})
+	locker.On("Lock").Return(secondLost, nil).Run(func(mock.Arguments) { close(relocked) }).Once()
+	locker.On("Unlock").Return(nil).Twice()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- elector.Run(ctx) }()
+	assert.True(t, waitForLeader(elector))
+
+	// Losing the lock gives up leadership and campaigns again
+	close(firstLost)
+	<-relocked
+	assert.True(t, waitForLeader(elector))
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.False(t, elector.IsLeader())
+	locker.AssertExpectations(t)
+}
@@ -1,8 +1,10 @@
 package alice
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -27,8 +29,11 @@ type DatadogMonitor struct {
 }
 
 // GetUpdatedMetrics returns MetricUpdates for each of the metrics requested
-func (d *DatadogMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, error) {
+func (d *DatadogMonitor) GetUpdatedMetrics(ctx context.Context, names []string) (*[]MetricUpdate, error) {
 	response := make([]MetricUpdate, len(names))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if !d.haveCheckedAPIKey {
 		v, err := d.Client.Validate() // validate API key
 		if err != nil {
@@ -49,6 +54,9 @@ func (d *DatadogMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, err
 		if !metricConfig.IsSet(metric + ".query") {
 			return nil, fmt.Errorf("Must provide datadog query for metric %s", metric)
 		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		query := metricConfig.GetString(metric + ".query")
 		d.log.Debugln("/v1/query?from=" + strconv.FormatInt(from.Unix(), 10) + "&to=" + strconv.FormatInt(to.Unix(), 10) + "&query=" + query)
 		result, err := d.Client.QueryMetrics(from.Unix(), to.Unix(), query)
@@ -60,20 +68,54 @@ func (d *DatadogMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, err
 		if len(result) != 1 || len(result[0].Points) < 1 {
 			return nil, fmt.Errorf("No data for %v between %v and %v", metric, from, to)
 		}
+		last := result[0].Points[len(result[0].Points)-1]
 		response[index].Name = metric
-		response[index].CurrentReading = result[0].Points[len(result[0].Points)-1][1]
+		response[index].CurrentReading = last[1]
+		// Datadog timestamps are in milliseconds
+		response[index].Timestamp = time.Unix(0, int64(last[0])*int64(time.Millisecond))
+		response[index].Samples = len(result[0].Points)
 	}
 	return &response, nil
 }
 
+// Validate checks there is a query for every metric, and that time_period is positive
+func (d *DatadogMonitor) Validate() []error {
+	var errs []error
+	if d.config.GetDuration("time_period") <= 0 {
+		errs = append(errs, configErrorf("time_period", "Must be a positive duration"))
+	}
+	if !d.config.IsSet("metrics") {
+		return append(errs, configErrorf("metrics", "Must provide 'metrics' config"))
+	}
+	for metric := range d.config.GetStringMap("metrics") {
+		if d.config.GetString("metrics."+metric+".query") == "" {
+			errs = append(errs, configErrorf("metrics."+metric+".query", "Must provide datadog query for metric %s", metric))
+		}
+	}
+	return errs
+}
+
+// DatadogConfig is the configuration of a DatadogMonitor
+type DatadogConfig struct {
+	APIKey     string                         `config:"api_key" required:"true" description:"Datadog API key"`
+	AppKey     string                         `config:"app_key" required:"true" description:"Datadog application key"`
+	TimePeriod time.Duration                  `config:"time_period" required:"true" description:"The most recent data point must be within this time"`
+	Metrics    map[string]DatadogMetricConfig `config:"metrics" description:"The metrics to query, by metric name"`
+	Timeout    time.Duration                  `config:"timeout" default:"30s" description:"Give up on a call to Datadog after this long"`
+}
+
+// DatadogMetricConfig is the configuration of a single metric in a DatadogMonitor
+type DatadogMetricConfig struct {
+	Query string `config:"query" description:"Datadog query giving the metric's value"`
+}
+
 // NewDatadogMonitor returns a new DatadogMonitor
 func NewDatadogMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, error) {
-	requiredConfig := []string{"api_key", "app_key", "time_period"}
-	for _, item := range requiredConfig {
-		if !config.IsSet(item) {
-			log.Fatalf("Missing config: %v", item)
-		}
+	var c DatadogConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
 	}
-	client := datadog.NewClient(config.GetString("api_key"), config.GetString("app_key"))
+	client := datadog.NewClient(c.APIKey, c.AppKey)
+	client.HttpClient = &http.Client{Timeout: c.Timeout}
 	return &DatadogMonitor{log: log, config: config, Client: client}, nil
 }
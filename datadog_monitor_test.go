@@ -1,6 +1,7 @@
 package alice_test
 
 import (
+	"context"
 	"errors"
 	"github.com/Sirupsen/logrus"
 	"github.com/notonthehighstreet/alice"
@@ -51,18 +52,20 @@ func TestDatadogMonitor_GetUpdatedMetrics(t *testing.T) {
 	metrics := []string{"foo.bar.baz"}
 	mockResponse := []datadog.Series{
 		{Points: []datadog.DataPoint{
-			{float64(time.Now().Unix() - 2), 0.9},
-			{float64(time.Now().Unix() - 1), 0.3},
-			{float64(time.Now().Unix()), 0.5},
+			{1484085598000, 0.9},
+			{1484085599000, 0.3},
+			{1484085600500, 0.5},
 		}},
 	}
 	mockDatadogClient.On("Validate").Return(true, nil)
 	mockDatadogClient.On("QueryMetrics").Return(mockResponse, nil)
-	vp, err := datadogMon.GetUpdatedMetrics(metrics)
+	vp, err := datadogMon.GetUpdatedMetrics(ctx, metrics)
 	assert.NoError(t, err)
 	val := *vp
 	assert.Equal(t, 1, len(val))
 	assert.Equal(t, 0.5, val[0].CurrentReading)
+	assert.Equal(t, time.Date(2017, 1, 10, 22, 0, 0, 500*int(time.Millisecond), time.UTC), val[0].Timestamp.UTC())
+	assert.Equal(t, 3, val[0].Samples)
 }
 
 func TestDatadogMonitor_GetUpdatedMetricsNoData(t *testing.T) {
@@ -72,24 +75,69 @@ func TestDatadogMonitor_GetUpdatedMetricsNoData(t *testing.T) {
 
 	mockResponse := []datadog.Series{}
 	mockDatadogClient.On("QueryMetrics").Return(mockResponse, nil).Once()
-	_, err1 := datadogMon.GetUpdatedMetrics(metrics)
+	_, err1 := datadogMon.GetUpdatedMetrics(ctx, metrics)
 	assert.Error(t, err1)
 
 	mockResponse = []datadog.Series{
 		{Points: []datadog.DataPoint{}},
 	}
 	mockDatadogClient.On("QueryMetrics").Return(mockResponse, nil).Once()
-	_, err2 := datadogMon.GetUpdatedMetrics(metrics)
+	_, err2 := datadogMon.GetUpdatedMetrics(ctx, metrics)
 	assert.Error(t, err2)
 }
 
+func TestDatadogMonitor_GetUpdatedMetricsCancelled(t *testing.T) {
+	setupDatadogMonitorTest()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := datadogMon.GetUpdatedMetrics(cancelled, []string{"foo.bar.baz"})
+	assert.Error(t, err)
+	mockDatadogClient.AssertNotCalled(t, "QueryMetrics")
+}
+
 func TestDatadogMonitorInvalidApiKey(t *testing.T) {
 	setupDatadogMonitorTest()
 	metrics := []string{"foo.bar.baz"}
 	mockDatadogClient.On("Validate").Return(false, nil).Once()
-	_, eA := datadogMon.GetUpdatedMetrics(metrics)
+	_, eA := datadogMon.GetUpdatedMetrics(ctx, metrics)
 	assert.Error(t, eA)
 	mockDatadogClient.On("Validate").Return(true, errors.New("")).Once()
-	_, eB := datadogMon.GetUpdatedMetrics(metrics)
+	_, eB := datadogMon.GetUpdatedMetrics(ctx, metrics)
 	assert.Error(t, eB)
 }
+
+func TestDatadogMonitor_Validate(t *testing.T) {
+	setupDatadogMonitorTest()
+	assert.Len(t, datadogMon.Validate(), 1)
+	config.Set("metrics.queue.query", "avg:queue.size{*}")
+	config.Set("metrics.cpu.query", "")
+	errs := datadogMon.Validate()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "metrics.cpu.query")
+
+	config = viper.New()
+	_, err := alice.NewDatadogMonitor(config, log)
+	assert.Error(t, err)
+}
+
+func TestNewDatadogMonitor_MissingConfig(t *testing.T) {
+	// A missing key is an error rather than a reason to exit
+	settings := map[string]string{"api_key": "foo", "app_key": "bar", "time_period": "5m"}
+	for missing := range settings {
+		config := viper.New()
+		for key, value := range settings {
+			if key != missing {
+				config.Set(key, value)
+			}
+		}
+		_, err := alice.NewDatadogMonitor(config, log)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), missing)
+		}
+	}
+
+	setupDatadogMonitorTest()
+	mockDatadogClient.On("Validate").Return(true, nil)
+	_, err := datadogMon.GetUpdatedMetrics(ctx, []string{"foo.bar.baz"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package alice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Elector represents the generic leader election interface. When several copies of alice run side by side, an
+// elector makes sure only one of them (the leader) changes inventories. The others keep evaluating their strategies
+// but act as though they were running in advisory mode.
+type Elector interface {
+	// Run campaigns for leadership until the context is cancelled, giving up leadership on the way out
+	Run(ctx context.Context) error
+	// IsLeader returns true while this instance holds leadership
+	IsLeader() bool
+}
+
+// Create a hash for storing the names of registered electors and their New() methods
+// eg {'foo': foo.New(), 'bar': bar.New(), 'baz': baz.New()}
+type electorFactoryFunc func(config *viper.Viper, log *logrus.Entry) (Elector, error)
+
+var electors = make(map[string]electorFactoryFunc)
+
+// RegisterElector allows a new elector type to be registered with a string name. This name is used to match
+// configuration to the correct NewFooElector function that can read it.
+func RegisterElector(name string, factory electorFactoryFunc) {
+	if factory == nil {
+		logrus.Panicf("New() for %s does not exist.", name)
+	}
+	_, registered := electors[name]
+	if registered {
+		logrus.Errorf("New() for %s already registered. Ignoring.", name)
+	}
+	electors[name] = factory
+}
+
+// NewElector will take a generic block of configuration and read look for a 'name' key, and pass the
+// block of config to the factory function that has been registered with that name. If the plugin has registered a
+// config struct with RegisterConfig, the config is checked against it first.
+func NewElector(config *viper.Viper, log *logrus.Entry) (Elector, error) {
+	if !config.IsSet("name") {
+		return nil, errors.New("No elector name provided")
+	}
+	name := config.GetString("name")
+	newFunc, ok := electors[name]
+	if !ok {
+		return nil, fmt.Errorf("Invalid elector name %s. Must be one of: %s", name, strings.Join(Electors(), ", "))
+	}
+	if err := checkConfig(ElectorPlugin, name, config); err != nil {
+		return nil, err
+	}
+	return newFunc(config, log.WithField("elector", name))
+}
+
+// Electors returns the names of the registered electors, sorted
+func Electors() []string {
+	names := make([]string, 0, len(electors))
+	for name := range electors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
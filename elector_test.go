@@ -0,0 +1,50 @@
+package alice_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockElector struct {
+	mock.Mock
+}
+
+func (m *MockElector) Run(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockElector) IsLeader() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func NewMockElector(config *viper.Viper, log *logrus.Entry) (alice.Elector, error) {
+	return &MockElector{}, nil
+}
+
+func init() {
+	alice.RegisterElector("mock", NewMockElector)
+}
+
+func TestNewElector(t *testing.T) {
+	config := viper.New()
+	_, err := alice.NewElector(config, log)
+	assert.Error(t, err)
+
+	config.Set("name", "missing")
+	_, err = alice.NewElector(config, log)
+	assert.Error(t, err)
+
+	config.Set("name", "mock")
+	elector, err := alice.NewElector(config, log)
+	assert.NoError(t, err)
+	assert.IsType(t, &MockElector{}, elector)
+	assert.Contains(t, alice.Electors(), "mock")
+}
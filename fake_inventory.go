@@ -1,6 +1,8 @@
 package alice
 
 import (
+	"context"
+	"errors"
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -13,26 +15,29 @@ type FakeInventory struct {
 }
 
 // Total returns the current total number of resources
-func (f *FakeInventory) Total() (int, error) {
+func (f *FakeInventory) Total(_ context.Context) (int, error) {
 	return f.total, nil
 }
 
-// Increase (scale up) the number of resources in the inventory
-func (f *FakeInventory) Increase() error {
-	f.total++
+// Increase (scale up) the number of resources in the inventory by amount
+func (f *FakeInventory) Increase(_ context.Context, amount int) error {
+	f.total += amount
 	f.log.Infof("Fake inventory contains %v resources", f.total)
 	return nil
 }
 
-// Decrease (scale down) the number of resources in the inventory
-func (f *FakeInventory) Decrease() error {
-	f.total--
+// Decrease (scale down) the number of resources in the inventory by amount
+func (f *FakeInventory) Decrease(_ context.Context, amount int) error {
+	if amount > f.total {
+		return errors.New("Won't scale below zero resources")
+	}
+	f.total -= amount
 	f.log.Infof("Fake inventory contains %v resources", f.total)
 	return nil
 }
 
 // Status returns OK if the inventory is ready to be scaled, UPDATING if an update is in progress, or FAILED
-func (f *FakeInventory) Status() (Status, error) {
+func (f *FakeInventory) Status(_ context.Context) (Status, error) {
 	return OK, nil
 }
 
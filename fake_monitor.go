@@ -1,7 +1,9 @@
 package alice
 
 import (
+	"context"
 	"math"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -17,13 +19,15 @@ type FakeMonitor struct {
 }
 
 // GetUpdatedMetrics returns MetricUpdates for each of the metrics requested
-func (f *FakeMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, error) {
+func (f *FakeMonitor) GetUpdatedMetrics(_ context.Context, names []string) (*[]MetricUpdate, error) {
 	response := make([]MetricUpdate, len(names))
 	fakeReading := f.generateFakeReading()
 	f.log.Infof("Setting all metrics to the fake reading %v", fakeReading)
+	now := time.Now()
 	for i, name := range names {
 		response[i].Name = name
 		response[i].CurrentReading = float64(fakeReading)
+		response[i].Timestamp = now
 	}
 	return &response, nil
 }
@@ -36,8 +40,15 @@ func (f *FakeMonitor) generateFakeReading() int {
 	return int(output)
 }
 
+// FakeMonitorConfig is the configuration of a FakeMonitor
+type FakeMonitorConfig struct {
+	Increments int `config:"increments" default:"10" description:"How far the fake reading moves around its sine wave each time, in degrees"`
+}
+
 // NewFakeMonitor returns a new Monitor
 func NewFakeMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, error) {
-	config.SetDefault("increments", 10)
+	if err := LoadConfig(config, &FakeMonitorConfig{}); err != nil {
+		return nil, err
+	}
 	return &FakeMonitor{config: config, log: log, iteration: 0}, nil
 }
@@ -0,0 +1,75 @@
+package alice
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// FileElector holds leadership for as long as it holds an exclusive lock on a local file. It only works for copies of
+// alice running on the same machine, so is mostly useful for trying out leader election locally.
+type FileElector struct {
+	log    *logrus.Entry
+	config *viper.Viper
+	mu     sync.RWMutex
+	leader bool
+}
+
+// FileElectorConfig is the configuration of a FileElector
+type FileElectorConfig struct {
+	Path          string        `config:"path" required:"true" description:"File to lock, the same for every copy of alice"`
+	RetryInterval time.Duration `config:"retry_interval" default:"5s" description:"How often to try to take the lock"`
+}
+
+// NewFileElector creates a new Elector
+func NewFileElector(config *viper.Viper, log *logrus.Entry) (Elector, error) {
+	if err := LoadConfig(config, &FileElectorConfig{}); err != nil {
+		return nil, err
+	}
+	return &FileElector{log: log, config: config}, nil
+}
+
+// Run campaigns for leadership until the context is cancelled, giving up leadership on the way out
+func (f *FileElector) Run(ctx context.Context) error {
+	file, err := os.OpenFile(f.config.GetString("path"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for {
+		if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+			break
+		} else if err != syscall.EWOULDBLOCK {
+			return err
+		}
+		select {
+		case <-time.After(f.config.GetDuration("retry_interval")):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.log.Warn("Became the leader")
+	f.setLeader(true)
+	<-ctx.Done()
+	f.setLeader(false)
+	f.log.Info("Giving up leadership")
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// IsLeader returns true while this instance holds the lock
+func (f *FileElector) IsLeader() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.leader
+}
+
+func (f *FileElector) setLeader(leader bool) {
+	f.mu.Lock()
+	f.leader = leader
+	f.mu.Unlock()
+}
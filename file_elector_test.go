@@ -0,0 +1,62 @@
+package alice_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFileElector(t *testing.T) {
+	_, err := alice.NewFileElector(viper.New(), log)
+	assert.Error(t, err)
+}
+
+func TestFileElector_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alice")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := viper.New()
+	config.Set("path", filepath.Join(dir, "leader.lock"))
+	config.Set("retry_interval", "10ms")
+
+	first, err := alice.NewFileElector(config, log)
+	assert.NoError(t, err)
+	second, err := alice.NewFileElector(config, log)
+	assert.NoError(t, err)
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() { firstDone <- first.Run(firstCtx) }()
+	assert.True(t, waitForLeader(first), "first elector should become the leader")
+
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	secondDone := make(chan error)
+	go func() { secondDone <- second.Run(secondCtx) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, second.IsLeader(), "second elector should not lead while the first holds the lock")
+
+	stopFirst()
+	assert.NoError(t, <-firstDone)
+	assert.False(t, first.IsLeader())
+	assert.True(t, waitForLeader(second), "second elector should take over")
+
+	stopSecond()
+	assert.NoError(t, <-secondDone)
+}
+
+func waitForLeader(e alice.Elector) bool {
+	for i := 0; i < 100; i++ {
+		if e.IsLeader() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
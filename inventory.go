@@ -1,7 +1,10 @@
 package alice
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -11,11 +14,13 @@ import (
 // Inventory represents the generic inventory interface. An inventory can manage any type of resource (server instances,
 // application instances, sheep etc). As long as it can return a total, be scaled up and down, and let us know if the
 // inventory is healthy, then it will work..
+// Increase and Decrease take the number of resources to add or remove, which is always positive. Every call takes a
+// context, which inventories should pass on to any remote calls they make so that they can be cancelled.
 type Inventory interface {
-	Total() (int, error)
-	Increase() error
-	Decrease() error
-	Status() (Status, error)
+	Total(ctx context.Context) (int, error)
+	Increase(ctx context.Context, amount int) error
+	Decrease(ctx context.Context, amount int) error
+	Status(ctx context.Context) (Status, error)
 }
 
 // Status represents the various statuses that can be returned by an inventory's Status() function.
@@ -30,6 +35,18 @@ const (
 	FAILED
 )
 
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case UPDATING:
+		return "UPDATING"
+	case FAILED:
+		return "FAILED"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
 // Create a hash for storing the names of registered inventories and their New() methods
 // eg {'foo': foo.New(), 'bar': bar.New(), 'baz': baz.New()}
 type inventoryFactoryFunc func(config *viper.Viper, log *logrus.Entry) (Inventory, error)
@@ -49,8 +66,9 @@ func RegisterInventory(name string, factory inventoryFactoryFunc) {
 	inventories[name] = factory
 }
 
-// NewInventory will take a generic block of configuration and read look for a 'name' key, and immediately pass
-// the block of config to the factory function that has been registered with that name.
+// NewInventory will take a generic block of configuration and read look for a 'name' key, and pass the
+// block of config to the factory function that has been registered with that name. If the plugin has registered a
+// config struct with RegisterConfig, the config is checked against it first.
 func NewInventory(config *viper.Viper, log *logrus.Entry) (Inventory, error) {
 	// Find the correct inventory and return it
 	if !config.IsSet("name") {
@@ -59,14 +77,21 @@ func NewInventory(config *viper.Viper, log *logrus.Entry) (Inventory, error) {
 	name := config.GetString("name")
 	newFunc, ok := inventories[name]
 	if !ok {
-		// Inventory has not been registered.
-		// Make a list of all available inventories for logging.
-		available := make([]string, len(inventories))
-		for k := range inventories {
-			available = append(available, k)
-		}
-		log.Fatalf("Invalid inventory name. Must be one of: %s", strings.Join(available, ", "))
+		return nil, fmt.Errorf("Invalid inventory name %s. Must be one of: %s", name, strings.Join(Inventories(), ", "))
+	}
+	if err := checkConfig(InventoryPlugin, name, config); err != nil {
+		return nil, err
 	}
 	return newFunc(config, log.WithField("inventory", name))
 
 }
+
+// Inventories returns the names of the registered inventories, sorted
+func Inventories() []string {
+	names := make([]string, 0, len(inventories))
+	for name := range inventories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
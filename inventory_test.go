@@ -1,6 +1,7 @@
 package alice_test
 
 import (
+	"context"
 	"github.com/Sirupsen/logrus"
 	"github.com/notonthehighstreet/alice"
 	"github.com/spf13/viper"
@@ -13,21 +14,21 @@ type MockInventory struct {
 	mock.Mock
 }
 
-func (m *MockInventory) Total() (int, error) {
+func (m *MockInventory) Total(_ context.Context) (int, error) {
 	args := m.Mock.Called()
 	return args.Get(0).(int), args.Error(1)
 }
-func (m *MockInventory) Increase() error {
-	args := m.Mock.Called()
+func (m *MockInventory) Increase(_ context.Context, amount int) error {
+	args := m.Mock.Called(amount)
 	return args.Error(0)
 }
-func (m *MockInventory) Decrease() error {
-	args := m.Mock.Called()
+func (m *MockInventory) Decrease(_ context.Context, amount int) error {
+	args := m.Mock.Called(amount)
 	return args.Error(0)
 }
-func (m *MockInventory) Status() (alice.Status, error) {
+func (m *MockInventory) Status(_ context.Context) (alice.Status, error) {
 	args := m.Mock.Called()
-	return args.Get(0).(alice.Status), nil
+	return args.Get(0).(alice.Status), args.Error(1)
 }
 
 func NewMockInventory(_ *viper.Viper, _ *logrus.Entry) (alice.Inventory, error) {
@@ -46,4 +47,18 @@ func TestNewInventory(t *testing.T) {
 	setupInventoryTest()
 	i, _ := alice.NewInventory(config, log)
 	assert.IsType(t, &MockInventory{}, i)
+
+	config.Set("name", "unknown")
+	_, err := alice.NewInventory(config, log)
+	assert.Error(t, err)
+	assert.Contains(t, alice.Inventories(), "mock")
+}
+
+func TestFakeInventory(t *testing.T) {
+	inv, _ := alice.NewFakeInventory(viper.New(), log)
+	assert.NoError(t, inv.Increase(ctx, 2))
+	assert.NoError(t, inv.Decrease(ctx, 12))
+	total, _ := inv.Total(ctx)
+	assert.Equal(t, 0, total)
+	assert.Error(t, inv.Decrease(ctx, 1))
 }
@@ -1,6 +1,15 @@
 package alice
 
 import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -9,81 +18,546 @@ import (
 // Manager ties together the inventory and the strategy. It will evaluate the strategy and will execute scaling actions
 // on the inventory based on the recommendation it received.
 type Manager struct {
+	Name      string
 	Inventory Inventory
+	Monitor   Monitor
 	Logger    *logrus.Entry
 	Strategy  Strategy
 	Config    *viper.Viper
+	Clock     func() time.Time // Defaults to time.Now
+	Audit     *AuditLog        // Optional
+	Elector   Elector          // Optional, without one the manager always acts as the leader
+	Notifier  Notifier         // Optional
+	breach    breach
+	running   sync.Mutex // Held while the manager is changing the inventory
+	mu        sync.Mutex
+	status    ManagerStatus
+	override  *Override
+	windows   []window
+	history   []scaling // Recent scaling actions, for rate limiting
+	failing   struct {  // What was failing at the end of the last run, to notify only when it starts failing
+		inventory, monitor, stale bool
+	}
+	pending   *PendingAction // Waiting for approval
+	approvals int            // How many approvals have been asked for, to number them
+}
+
+// ManagerStatus describes what a manager found and did the last time it ran
+type ManagerStatus struct {
+	Name            string                   `json:"name"`
+	Inventory       string                   `json:"inventory"`
+	Monitor         string                   `json:"monitor"`
+	Strategy        string                   `json:"strategy"`
+	LastEvaluation  time.Time                `json:"last_evaluation"`
+	Recommendation  *Recommendation          `json:"recommendation"`
+	Metrics         []MetricUpdate           `json:"metrics"`
+	Action          Action                   `json:"action"`
+	Reason          string                   `json:"reason,omitempty"`
+	InventoryTotal  *int                     `json:"inventory_total"`
+	InventoryStatus string                   `json:"inventory_status,omitempty"`
+	LastError       string                   `json:"last_error,omitempty"`
+	Override        *Override                `json:"override,omitempty"`
+	Pending         *PendingAction           `json:"pending,omitempty"`    // Scaling action waiting for approval
+	Schedules       []string                 `json:"schedules,omitempty"`  // Schedule windows active during the run
+	Strategies      []StrategyRecommendation `json:"strategies,omitempty"` // What each strategy recommended, when combining several
+	Evaluation      *Evaluation              `json:"evaluation,omitempty"` // Why the strategy recommended what it did
 }
 
 // New creates a new Manager
-func New(config *viper.Viper, log *logrus.Entry) (Manager, error) {
-	requiredKeys := []string{"inventory", "monitor", "strategy"}
+func New(name string, config *viper.Viper, log *logrus.Entry) (*Manager, error) {
+	requiredKeys := []string{"inventory", "strategy"}
 	for _, k := range requiredKeys {
 		if !config.IsSet(k) {
-			log.Fatalf("Missing %v definition", k)
+			return nil, errors.Errorf("Missing %v definition", k)
 		}
 	}
 
 	log.Info("Initialising inventory")
 	inv, err := NewInventory(config.Sub("inventory"), log)
 	if err != nil {
-		return Manager{}, errors.Wrap(err, "Error initialization inventory")
+		return nil, errors.Wrap(err, "Error initialization inventory")
 	}
 
 	log.Info("Initialising monitor")
-	monitor, err := NewMonitor(config.Sub("monitor"), log)
+	monitor, _, err := newManagerMonitor(config, log)
 	if err != nil {
-		return Manager{}, errors.Wrap(err, "Error initialization monitor")
+		return nil, errors.Wrap(err, "Error initialization monitor")
 	}
 
+	mon := &monitorRecorder{Monitor: monitor}
+
 	log.Info("Initialising strategy")
-	str, err := NewStrategy(config.Sub("strategy"), inv, monitor, log)
+	str, err := NewStrategy(config.Sub("strategy"), inv, mon, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error initializing strategy")
+	}
+
+	windows, err := newWindows(config)
 	if err != nil {
-		return Manager{}, errors.Wrap(err, "Error initializing strategy")
+		return nil, err
 	}
 
-	return Manager{Strategy: str, Inventory: inv, Logger: log, Config: config}, nil
+	return &Manager{Name: name, Strategy: str, Inventory: inv, Monitor: mon, Logger: log, Config: config, windows: windows}, nil
 }
 
-// Run requests a recommendation from the strategy, and if not running in dry-run mode will attempt to scale up the
-// inventory.
-func (m *Manager) Run() error {
+// Run requests a recommendation from the strategy, and once it has persisted for long enough, if not running in
+// dry-run mode, will attempt to scale the inventory by the recommended step, capped at max_step_up or max_step_down if
+// they are configured. While the manager is paused or pinned the strategy is still evaluated, but its recommendation
+// is ignored. Cancelling the context abandons any remote calls still in progress.
+func (m *Manager) Run(ctx context.Context) (err error) {
+	m.running.Lock()
+	defer m.running.Unlock()
 	m.Logger.Info("Executing strategy")
-	rec, err := m.Strategy.Evaluate()
-	m.Config.SetDefault("scale_up", true)
-	m.Config.SetDefault("scale_down", true)
-	invName, stratName, monName := m.Config.GetString("inventory.name"), m.Config.GetString("strategy.name"), m.Config.GetString("monitor.name")
-	if err == nil {
-		switch *rec {
-		case SCALEUP:
-			if m.Config.GetBool("scale_up") {
-				err = m.Inventory.Increase()
-				if err != nil {
-					m.Logger.Infof("Can't scale up: %s", err.Error())
-				} else {
-					m.Logger.Warnf("Scaling up our %s inventory based on the %s strategy using information from %s", invName, stratName, monName)
-				}
-			} else {
-				m.Logger.Warnf("I would have scaled up our %s inventory based on the %s strategy using information from %s but am running in advisory mode", invName, stratName, monName)
-			}
-		case HOLD:
+	evaluationsTotal.WithLabelValues(m.Name).Inc()
+	started := time.Now()
+	run := AuditRecord{Time: m.now(), Manager: m.Name, Action: ActionNone}
+	defer m.recover(ctx, &run, &err)
+	rec, err := m.evaluate(ctx)
+	evaluationDuration.WithLabelValues(m.Name).Observe(time.Since(started).Seconds())
+	run.Recommendation = rec
+	if str, ok := m.Strategy.(CombinedStrategy); ok {
+		run.Strategies = str.Recommendations()
+	}
+	if str, ok := m.Strategy.(ExplainedStrategy); ok && err == nil {
+		if run.Evaluation = str.Explain(); run.Evaluation != nil && run.Evaluation.String() != "" {
+			m.Logger.Infof("The strategy recommends %v because %s", *rec, run.Evaluation)
+		}
+	}
+	override := m.Override()
+	if override != nil {
+		// Recommendations made before the override shouldn't count towards confirming ones made after it
+		m.breach.reset()
+	}
+	stale := m.staleMetrics(run.Time)
+	if err != nil {
+		m.breach.reset()
+		run.Reason = "The strategy failed to make a recommendation"
+	} else if override != nil && override.Kind == OverridePin {
+		err = m.pin(ctx, &run, override)
+	} else if override != nil && *rec != HOLD {
+		m.Logger.Infof("Ignoring recommendation to scale %s by %d as the manager is %s", rec.direction(), rec.Step(), override)
+		run.Action, run.Reason = ActionPaused, override.String()
+	} else if len(stale) > 0 && *rec != HOLD {
+		// Stale readings shouldn't count towards confirming a recommendation either
+		m.breach.reset()
+		m.Logger.Warnf("Holding instead of scaling %s by %d, as metrics are older than max_metric_age: %s", rec.direction(), rec.Step(), strings.Join(stale, ", "))
+		run.Direction, run.Step = rec.direction(), rec.Step()
+		run.Action, run.Reason = ActionStale, strings.Join(stale, ", ")
+	} else if !m.confirmed(*rec) {
+		m.Logger.Info("Doing nothing until the recommendation is confirmed")
+		run.Action, run.Reason = ActionUnconfirmed, "Waiting for the recommendation to persist"
+	} else {
+		switch {
+		case *rec > HOLD:
+			err = m.scale(ctx, &run, rec.direction(), m.limitStep(rec.Step(), "max_step_up"))
+		case *rec == HOLD:
 			m.Logger.Info("Doing nothing")
-		case SCALEDOWN:
-			if m.Config.GetBool("scale_down") {
-				err = m.Inventory.Decrease()
-				if err != nil {
-					m.Logger.Infof("Can't scale down: %s", err.Error())
-				} else {
-					m.Logger.Warnf("Scaling down our %s inventory based on the %s strategy using information from %s", invName, stratName, monName)
-				}
-			} else {
-				m.Logger.Warnf("I would have scaled down our %s inventory based on the %s strategy using information from %s but am running in advisory mode", invName, stratName, monName)
-			}
-		default:
-			err = errors.New("Unknown recommendation")
-
+			run.Reason = "The strategy recommended holding"
+		case *rec < HOLD:
+			err = m.scale(ctx, &run, rec.direction(), m.limitStep(rec.Step(), "max_step_down"))
+		}
+		if err == nil && run.Action == ActionScaled {
+			// The next scaling action has to be confirmed afresh, not ride on the breach that led to this one
+			m.breach.reset()
 		}
 	}
+	m.record(ctx, &run, err)
 	return err
+}
+
+// staleMetrics describes the metrics read during the run that are older than max_metric_age, if it is set
+func (m *Manager) staleMetrics(now time.Time) []string {
+	recorder, ok := m.Monitor.(*monitorRecorder)
+	if !ok || !m.Config.IsSet("max_metric_age") {
+		return nil
+	}
+	return recorder.stale(now, m.Config.GetDuration("max_metric_age"))
+}
+
+// recover stops a panic during a run from taking down the rest of alice. The run is recorded as failed and the panic
+// returned as its error. It must be deferred.
+func (m *Manager) recover(ctx context.Context, run *AuditRecord, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	m.Logger.Errorf("Recovered from a panic during the run: %v\n%s", r, debug.Stack())
+	*err = errors.Errorf("Panic during the run: %v", r)
+	run.Action, run.Reason = ActionNone, "The run failed unexpectedly"
+	defer func() {
+		// The panic may have come from the inventory or monitor, which record uses too
+		if r := recover(); r != nil {
+			m.Logger.Errorf("Can't record the failed run: %v", r)
+		}
+	}()
+	m.record(ctx, run, *err)
+}
+
+// scale changes the inventory by step in the given direction ("up" or "down"), unless scaling in that direction has
+// been disabled by setting scale_up or scale_down to false, or needs approval because it is set to 'approval'. What
+// happened is noted in the run's record.
+func (m *Manager) scale(ctx context.Context, run *AuditRecord, direction string, step int) error {
+	invName, stratName, monName := m.Config.GetString("inventory.name"), m.Config.GetString("strategy.name"), m.monitorName()
+	run.Direction, run.Step = direction, step
+	switch m.scaleMode(direction) {
+	case "disabled":
+		m.Logger.Warnf("I would have scaled %s our %s inventory by %d based on the %s strategy using information from %s but am running in advisory mode", direction, invName, step, stratName, monName)
+		run.Action, run.Reason = ActionAdvisory, "Scaling "+direction+" is disabled"
+		return nil
+	case "approval":
+		return m.requestApproval(ctx, run, direction, step)
+	}
+	return m.change(ctx, run, direction, step)
+}
+
+// change scales the inventory by step in the given direction, as long as this instance of alice is the leader. Whatever
+// asked for the change, the step is clipped so that the inventory total stays within min_total and max_total, and
+// within any rate limits. What happened is noted in the run's record.
+func (m *Manager) change(ctx context.Context, run *AuditRecord, direction string, step int) error {
+	invName, stratName, monName := m.Config.GetString("inventory.name"), m.Config.GetString("strategy.name"), m.monitorName()
+	run.Direction, run.Step = direction, step
+	if m.Elector != nil && !m.Elector.IsLeader() {
+		m.Logger.Infof("I would have scaled %s our %s inventory by %d based on the %s strategy using information from %s but am not the leader", direction, invName, step, stratName, monName)
+		run.Action, run.Reason = ActionAdvisory, "Not the leader"
+		return nil
+	}
+	total, err := m.Inventory.Total(ctx)
+	if err == nil {
+		run.TotalBefore = &total
+	}
+	if m.Config.IsSet("min_total") || m.Config.IsSet("max_total") || m.Config.IsSet("rate_limit") {
+		if err != nil {
+			m.Logger.Infof("Can't scale %s without knowing the inventory total: %s", direction, err.Error())
+			run.Action, run.Reason = ActionRefused, "Can't check limits without the inventory total"
+			return err
+		}
+		if step = m.limitTotal(total, direction, step); step == 0 {
+			run.Step = step
+			run.Reason = fmt.Sprintf("The inventory total of %d is at its limit", total)
+			return nil
+		}
+		var reason string
+		if step, reason = m.rateLimit(direction, step, total); step == 0 {
+			run.Step = step
+			run.Action, run.Reason = ActionRateLimited, reason
+			return nil
+		}
+		run.Step = step
+	}
+	scaleAttemptsTotal.WithLabelValues(m.Name, direction).Inc()
+	if direction == "up" {
+		err = m.Inventory.Increase(ctx, step)
+	} else {
+		err = m.Inventory.Decrease(ctx, step)
+	}
+	if err != nil {
+		scaleActionsTotal.WithLabelValues(m.Name, direction, "refused").Inc()
+		m.Logger.Infof("Can't scale %s: %s", direction, err.Error())
+		run.Action, run.Reason = ActionRefused, err.Error()
+		return err
+	}
+	scaleActionsTotal.WithLabelValues(m.Name, direction, "succeeded").Inc()
+	if run.TotalBefore != nil {
+		m.remember(direction, step, total)
+	}
+	m.Logger.Warnf("Scaling %s our %s inventory by %d based on the %s strategy using information from %s", direction, invName, step, stratName, monName)
+	run.Action = ActionScaled
+	return nil
+}
+
+// Status returns what the manager found and did the last time it ran, and any override currently in force
+func (m *Manager) Status() ManagerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	status.Override = m.currentOverride()
+	status.Pending = m.currentPending()
+	return status
+}
+
+// record keeps the outcome of a run, along with the metric readings taken and the state of the inventory afterwards,
+// and writes it to the audit log if there is one. Unless a scaling action was attempted the inventory total before the
+// run is taken to be the same as after it.
+func (m *Manager) record(ctx context.Context, run *AuditRecord, runErr error) {
+	var failures int
+	if recorder, ok := m.Monitor.(*monitorRecorder); ok {
+		run.Metrics, failures = recorder.take()
+		monitorErrorsTotal.WithLabelValues(m.Name).Add(float64(failures))
+	}
+	for _, metric := range run.Metrics {
+		metricReading.WithLabelValues(m.Name, metric.Name).Set(metric.CurrentReading)
+	}
+	if run.Recommendation != nil {
+		recommendationsTotal.WithLabelValues(m.Name, run.Recommendation.direction()).Inc()
+	}
+	if total, err := m.Inventory.Total(ctx); err == nil {
+		run.TotalAfter = &total
+		inventoryTotal.WithLabelValues(m.Name).Set(float64(total))
+	} else {
+		m.Logger.Infof("Can't get inventory total: %s", err.Error())
+	}
+	if run.TotalBefore == nil {
+		run.TotalBefore = run.TotalAfter
+	}
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	status := ManagerStatus{
+		Name:           m.Name,
+		Inventory:      m.Config.GetString("inventory.name"),
+		Monitor:        m.monitorName(),
+		Strategy:       m.Config.GetString("strategy.name"),
+		LastEvaluation: run.Time,
+		Recommendation: run.Recommendation,
+		Metrics:        run.Metrics,
+		Action:         run.Action,
+		Reason:         run.Reason,
+		InventoryTotal: run.TotalAfter,
+		LastError:      run.Error,
+		Strategies:     run.Strategies,
+		Evaluation:     run.Evaluation,
+	}
+	for _, w := range m.activeWindows() {
+		status.Schedules = append(status.Schedules, w.name)
+	}
+	if invStatus, err := m.Inventory.Status(ctx); err == nil {
+		status.InventoryStatus = invStatus.String()
+	} else {
+		m.Logger.Infof("Can't get inventory status: %s", err.Error())
+	}
+	m.mu.Lock()
+	m.status = status
+	m.mu.Unlock()
+	if m.Audit != nil {
+		if err := m.Audit.Write(*run); err != nil {
+			m.Logger.Errorf("Can't write to audit log: %s", err.Error())
+		}
+	}
+	m.notify(ctx, run, status, failures > 0)
+}
 
+// notify tells the notifier, if there is one, about anything worth knowing from a run: scaling actions, and the
+// inventory or monitor starting to fail or recovering. Only the leader sends notifications, so that running several copies of alice
+// doesn't send each one several times.
+func (m *Manager) notify(ctx context.Context, run *AuditRecord, status ManagerStatus, monitorFailing bool) {
+	inventoryFailed := status.InventoryStatus == FAILED.String()
+	m.mu.Lock()
+	if status.InventoryStatus == "" {
+		// The inventory's status couldn't be read, so it's the same as it was
+		inventoryFailed = m.failing.inventory
+	}
+	var events []EventType
+	if inventoryFailed && !m.failing.inventory {
+		events = append(events, EventInventoryFailed)
+	}
+	if !inventoryFailed && m.failing.inventory {
+		events = append(events, EventInventoryRecovered)
+	}
+	if monitorFailing && !m.failing.monitor {
+		events = append(events, EventMonitorFailing)
+	}
+	if !monitorFailing && m.failing.monitor {
+		events = append(events, EventMonitorRecovered)
+	}
+	if run.Action == ActionStale && !m.failing.stale {
+		events = append(events, EventMetricsStale)
+	}
+	m.failing.inventory, m.failing.monitor = inventoryFailed, monitorFailing
+	m.failing.stale = run.Action == ActionStale
+	m.mu.Unlock()
+	if m.Notifier == nil || (m.Elector != nil && !m.Elector.IsLeader()) {
+		return
+	}
+	var pending *PendingAction
+	switch run.Action {
+	case ActionScaled, ActionForced, ActionApproved, ActionPinned:
+		if run.Step == 0 {
+			// Pinned, but already at the pinned total
+			break
+		}
+		if run.Direction == "up" {
+			events = append([]EventType{EventScaledUp}, events...)
+		} else {
+			events = append([]EventType{EventScaledDown}, events...)
+		}
+	case ActionAdvisory:
+		events = append([]EventType{EventAdvisory}, events...)
+	case ActionRefused:
+		events = append([]EventType{EventRefused}, events...)
+	case ActionPending:
+		// Only ask for approval once, when the pending action is made
+		if pending = m.Pending(); pending != nil && pending.Since.Equal(run.Time) {
+			events = append([]EventType{EventApprovalNeeded}, events...)
+		}
+	}
+	for _, t := range events {
+		event := Event{
+			Type:        t,
+			Manager:     m.Name,
+			Time:        run.Time,
+			Inventory:   status.Inventory,
+			Monitor:     status.Monitor,
+			Strategy:    status.Strategy,
+			Direction:   run.Direction,
+			Step:        run.Step,
+			TotalBefore: run.TotalBefore,
+			TotalAfter:  run.TotalAfter,
+			Reason:      run.Reason,
+			Error:       run.Error,
+			Pending:     pending,
+		}
+		if run.Action != ActionForced && run.Action != ActionPinned {
+			// Forced and pinned actions are taken regardless of the strategy, so its evaluation isn't why they happened
+			event.Evaluation = run.Evaluation
+		}
+		if err := m.Notifier.Notify(ctx, event); err != nil {
+			m.Logger.Errorf("Can't send %s notification: %s", t, err.Error())
+		}
+	}
+}
+
+// evaluate asks the strategy for a recommendation. In target mode the strategy returns the total the inventory should
+// be, which is kept within min_total and max_total and turned in to a recommendation to move straight there. Either
+// way the recommendation is then adjusted for any active schedule windows.
+func (m *Manager) evaluate(ctx context.Context) (*Recommendation, error) {
+	switch mode := m.Config.GetString("strategy.mode"); mode {
+	case "", "direction":
+		rec, err := m.Strategy.Evaluate(ctx)
+		if err != nil {
+			return rec, err
+		}
+		scheduled, err := m.schedule(ctx, *rec, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &scheduled, nil
+	case "target":
+	default:
+		return nil, errors.Errorf("Unknown strategy mode: %s", mode)
+	}
+	str, ok := m.Strategy.(TargetStrategy)
+	if !ok {
+		return nil, errors.Errorf("The %s strategy does not support target mode", m.Config.GetString("strategy.name"))
+	}
+	target, err := str.Target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if m.Config.IsSet("min_total") && target < m.Config.GetInt("min_total") {
+		m.Logger.Infof("Raising target of %d to min_total of %d", target, m.Config.GetInt("min_total"))
+		target = m.Config.GetInt("min_total")
+	}
+	if m.Config.IsSet("max_total") && target > m.Config.GetInt("max_total") {
+		m.Logger.Infof("Lowering target of %d to max_total of %d", target, m.Config.GetInt("max_total"))
+		target = m.Config.GetInt("max_total")
+	}
+	total, err := m.Inventory.Total(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.Logger.Infof("Target total is %d, current total is %d", target, total)
+	rec, err := m.schedule(ctx, Recommendation(target-total), &total)
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// newManagerMonitor creates the monitor for a manager's configuration: either its 'monitor', or a MonitorRouter for
+// its named 'monitors'. It also returns which of the two keys was used.
+func newManagerMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, string, error) {
+	switch {
+	case config.IsSet("monitor") && config.IsSet("monitors"):
+		return nil, "monitors", errors.New("Use either monitor or monitors, not both")
+	case config.IsSet("monitors"):
+		if config.Sub("monitors") == nil {
+			return nil, "monitors", errors.New("No monitors defined")
+		}
+		mon, err := NewMonitorRouter(config.Sub("monitors"), log)
+		return mon, "monitors", err
+	case config.IsSet("monitor"):
+		mon, err := NewMonitor(config.Sub("monitor"), log)
+		return mon, "monitor", err
+	}
+	return nil, "monitor", errors.New("Missing monitor definition")
+}
+
+// monitorName names the monitor plugin the manager uses, or its named monitors and their plugins if it has several
+func (m *Manager) monitorName() string {
+	if !m.Config.IsSet("monitors") {
+		return m.Config.GetString("monitor.name")
+	}
+	var names []string
+	for name := range m.Config.GetStringMap("monitors") {
+		names = append(names, name+"="+m.Config.GetString("monitors."+name+".name"))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (m *Manager) now() time.Time {
+	if m.Clock == nil {
+		return time.Now()
+	}
+	return m.Clock()
+}
+
+// inherit takes over the state old has built up while running, so that replacing a manager with one built from new
+// configuration doesn't lose an override, a pending approval, progress towards confirming a recommendation or the
+// history used for rate limiting. It waits for any run or control of old in progress to finish, then takes its
+// override and pending action away from it, so that they can't be approved or acted on twice.
+func (m *Manager) inherit(old *Manager) {
+	old.running.Lock()
+	defer old.running.Unlock()
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []string
+	if m.override = old.currentOverride(); m.override != nil {
+		kept = append(kept, m.override.String())
+	}
+	if m.pending = old.currentPending(); m.pending != nil {
+		kept = append(kept, "pending action "+strconv.Itoa(m.pending.ID))
+	}
+	old.override, old.pending = nil, nil
+	m.approvals, m.breach, m.history, m.failing, m.status = old.approvals, old.breach, old.history, old.failing, old.status
+	if len(kept) > 0 {
+		m.Logger.Warnf("Keeping state from before the configuration changed: %s", strings.Join(kept, ", "))
+	}
+}
+
+// limitTotal clips a step in the given direction so that it won't take the inventory total below min_total or above
+// max_total. It returns 0 if the total is already at (or beyond) the limit.
+func (m *Manager) limitTotal(total int, direction string, step int) int {
+	key, room := "max_total", m.Config.GetInt("max_total")-total
+	if direction == "down" {
+		key, room = "min_total", total-m.Config.GetInt("min_total")
+	}
+	if !m.Config.IsSet(key) || step <= room {
+		return step
+	}
+	if room <= 0 {
+		m.Logger.Infof("Not scaling %s by %d as the inventory total of %d is at %s of %d", direction, step, total, key, m.Config.GetInt(key))
+		return 0
+	}
+	m.Logger.Warnf("Clipping scale %s by %d to %d to keep the inventory total of %d within %s of %d", direction, step, room, total, key, m.Config.GetInt(key))
+	return room
+}
+
+// limitStep caps a step at the maximum configured under key. Steps are left alone if no maximum is configured.
+func (m *Manager) limitStep(step int, key string) int {
+	if !m.Config.IsSet(key) {
+		return step
+	}
+	max := m.Config.GetInt(key)
+	if max < 1 {
+		m.Logger.Errorf("Ignoring %s of %d as it must be at least 1", key, max)
+		return step
+	}
+	if step > max {
+		m.Logger.Infof("Limiting recommended step of %d to %s of %d", step, key, max)
+		return max
+	}
+	return step
 }
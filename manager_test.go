@@ -1,14 +1,19 @@
 package alice_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/notonthehighstreet/alice"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+var ctx = context.Background()
 var config = viper.New()
 var log = logrus.WithFields(logrus.Fields{
 	"manager": "Test",
@@ -31,6 +36,8 @@ func setupManagerTest() {
 	config.Set("inventory.name", "mock")
 	config.Set("strategy.name", "mock")
 	recommendation = alice.HOLD
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
 	man = alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config}
 
 }
@@ -38,7 +45,7 @@ func setupManagerTest() {
 func TestManager_Run(t *testing.T) {
 	setupManagerTest()
 	str.On("Evaluate").Return(&recommendation, nil).Once()
-	assert.NoError(t, man.Run())
+	assert.NoError(t, man.Run(ctx))
 }
 
 func TestScaleUpDisabled(t *testing.T) {
@@ -47,8 +54,8 @@ func TestScaleUpDisabled(t *testing.T) {
 	config.Set("scale_down", true)
 	recommendation = alice.SCALEUP
 	str.On("Evaluate").Return(&recommendation, nil).Once()
-	inv.On("Increase").Return(nil).Once()
-	man.Run()
+	inv.On("Increase", 1).Return(nil).Once()
+	man.Run(ctx)
 	inv.AssertNotCalled(t, "Increase")
 }
 
@@ -58,7 +65,195 @@ func TestScaleDownDisabled(t *testing.T) {
 	config.Set("scale_down", false)
 	recommendation = alice.SCALEDOWN
 	str.On("Evaluate").Return(&recommendation, nil).Once()
-	inv.On("Decrease").Return(nil).Once()
-	man.Run()
+	inv.On("Decrease", 1).Return(nil).Once()
+	man.Run(ctx)
 	inv.AssertNotCalled(t, "Decrease")
 }
+
+func TestManager_RunNotLeader(t *testing.T) {
+	setupManagerTest()
+	config.Set("scale_up", true)
+	config.Set("scale_down", true)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	elector := MockElector{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man = alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config, Elector: &elector}
+
+	recommendation = alice.SCALEUP
+	str.On("Evaluate").Return(&recommendation, nil)
+	elector.On("IsLeader").Return(false).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertNotCalled(t, "Increase", 1)
+	assert.Equal(t, alice.ActionAdvisory, man.Status().Action)
+
+	elector.On("IsLeader").Return(true).Once()
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionScaled, man.Status().Action)
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunPanic(t *testing.T) {
+	setupManagerTest()
+	inv := MockInventory{}
+	str := MockStrategy{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man = alice.Manager{Name: "web", Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	str.On("Evaluate").Return(nil, nil).Run(func(mock.Arguments) { panic("boom") }).Once()
+	err := man.Run(ctx)
+	assert.EqualError(t, err, "Panic during the run: boom")
+	assert.Equal(t, err.Error(), man.Status().LastError)
+
+	// The manager carries on as normal afterwards
+	recommendation = alice.HOLD
+	str.On("Evaluate").Return(&recommendation, nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Empty(t, man.Status().LastError)
+}
+
+func TestManager_RunStepLimits(t *testing.T) {
+	setupManagerTest()
+	config.Set("scale_up", true)
+	config.Set("scale_down", true)
+	config.Set("max_step_up", 3)
+	config.Set("max_step_down", 2)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man = alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+
+	recommendation = alice.Recommendation(5)
+	str.On("Evaluate").Return(&recommendation, nil).Once()
+	inv.On("Increase", 3).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	up := alice.Recommendation(2)
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	down := alice.Recommendation(-4)
+	str.On("Evaluate").Return(&down, nil).Once()
+	inv.On("Decrease", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunTotalLimits(t *testing.T) {
+	config := viper.New()
+	config.Set("min_total", 8)
+	config.Set("max_total", 12)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	man := alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	inv.On("Status").Return(alice.OK, nil)
+
+	inv.On("Total").Return(10, nil).Times(4)
+	up, down := alice.Recommendation(5), alice.Recommendation(-5)
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	str.On("Evaluate").Return(&down, nil).Once()
+	inv.On("Decrease", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	// Already at the limit, so nothing is changed, even when forced
+	inv.On("Total").Return(12, nil)
+	str.On("Evaluate").Return(&up, nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionNone, man.Status().Action)
+	assert.Error(t, man.Force(ctx, 1, ""))
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunTargetMode(t *testing.T) {
+	setupManagerTest()
+	config := viper.New()
+	config.Set("strategy.mode", "target")
+	config.Set("min_total", 5)
+	config.Set("max_total", 12)
+	inv := MockInventory{}
+	str := MockTargetStrategy{}
+	man = alice.Manager{Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+
+	str.On("Target").Return(11, nil).Once()
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	str.On("Target").Return(20, nil).Once()
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	str.On("Target").Return(0, nil).Once()
+	inv.On("Decrease", 5).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	str.On("Target").Return(10, nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+	str.AssertNotCalled(t, "Evaluate")
+
+	man.Strategy = &MockStrategy{}
+	assert.Error(t, man.Run(ctx))
+}
+
+func TestManager_Status(t *testing.T) {
+	setupManagerTest()
+	now := time.Date(2017, 2, 1, 12, 0, 0, 0, time.UTC)
+	mon := MockMonitor{}
+	man = alice.Manager{Name: "Test", Strategy: &str, Inventory: &inv, Monitor: &mon, Logger: log, Config: config, Clock: func() time.Time { return now }}
+	str.On("Evaluate").Return(&recommendation, nil).Once()
+	man.Run(ctx)
+	status := man.Status()
+	assert.Equal(t, "Test", status.Name)
+	assert.Equal(t, "mock", status.Strategy)
+	assert.Equal(t, now, status.LastEvaluation)
+	assert.Equal(t, alice.HOLD, *status.Recommendation)
+	assert.Equal(t, 10, *status.InventoryTotal)
+	assert.Equal(t, "OK", status.InventoryStatus)
+	assert.Empty(t, status.LastError)
+
+	str.On("Evaluate").Return(&recommendation, errors.New("Monitor is down")).Once()
+	man.Run(ctx)
+	assert.Equal(t, "Monitor is down", man.Status().LastError)
+}
+
+func TestManager_RunStaleMetrics(t *testing.T) {
+	now := time.Date(2017, 1, 10, 22, 0, 0, 0, time.UTC)
+	config := viper.New()
+	config.Set("inventory.name", "mock")
+	config.Set("monitor", map[string]interface{}{"name": "echo", "reading": 90, "timestamp": now.Add(-10 * time.Minute)})
+	config.Set("strategy", map[string]interface{}{"name": "threshold", "thresholds": map[string]interface{}{"cpu": map[string]interface{}{"max": 80}}})
+	config.Set("max_metric_age", "5m")
+	man, err := alice.New("web", config, log)
+	assert.NoError(t, err)
+	inv := MockInventory{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	notifier := MockNotifier{}
+	notifier.On("Notify", mock.Anything).Return(nil)
+	man.Inventory, man.Notifier = &inv, &notifier
+	man.Clock = func() time.Time { return now }
+
+	assert.NoError(t, man.Run(ctx))
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertNotCalled(t, "Increase", 1)
+	assert.Equal(t, alice.ActionStale, man.Status().Action)
+	assert.Equal(t, "cpu is 10m0s old", man.Status().Reason)
+	assert.Equal(t, now.Add(-10*time.Minute), man.Status().Metrics[0].Timestamp)
+	// Only the first run to hold on stale metrics is notified
+	assert.Len(t, notifier.events(), 1)
+	assert.Equal(t, alice.EventMetricsStale, notifier.events()[0].Type)
+
+	inv.On("Increase", 1).Return(nil).Once()
+	config.Set("max_metric_age", "15m")
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionScaled, man.Status().Action)
+	inv.AssertExpectations(t)
+}
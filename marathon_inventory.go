@@ -1,8 +1,9 @@
 package alice
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -24,17 +25,25 @@ type MarathonInventory struct {
 	lastModified time.Time
 }
 
+// MarathonConfig is the configuration of a MarathonInventory
+type MarathonConfig struct {
+	URL              string        `config:"url" required:"true" description:"URL of the Marathon API"`
+	App              string        `config:"app" required:"true" description:"Application ID in Marathon"`
+	MinimumInstances *int          `config:"minimum_instances" description:"Never scale the application below this many instances"`
+	MaximumInstances *int          `config:"maximum_instances" description:"Never scale the application above this many instances"`
+	SettleDownPeriod time.Duration `config:"settle_down_period" default:"0s" description:"How long to wait after scaling before scaling again"`
+	Timeout          time.Duration `config:"timeout" default:"30s" description:"Give up on a call to Marathon after this long"`
+}
+
 // NewMarathonInventory creates a new Inventory
 func NewMarathonInventory(config *viper.Viper, log *logrus.Entry) (Inventory, error) {
-	requiredConfig := []string{"app", "url"}
-	for _, item := range requiredConfig {
-		if !config.IsSet(item) {
-			return nil, fmt.Errorf("Missing config: %v", item)
-		}
+	var c MarathonConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
 	}
-	config.SetDefault("settle_down_period", "0s")
 	marathonConfig := marathon.NewDefaultConfig()
-	marathonConfig.URL = config.GetString("url")
+	marathonConfig.URL = c.URL
+	marathonConfig.HTTPClient = &http.Client{Timeout: c.Timeout}
 	client, err := marathon.NewClient(marathonConfig)
 	if err != nil {
 		return nil, err
@@ -44,33 +53,33 @@ func NewMarathonInventory(config *viper.Viper, log *logrus.Entry) (Inventory, er
 }
 
 // Total returns the current total number of resources
-func (m *MarathonInventory) Total() (int, error) {
-	app, err := m.GetApplication()
+func (m *MarathonInventory) Total(ctx context.Context) (int, error) {
+	app, err := m.GetApplication(ctx)
 	if err != nil {
 		return 0, err
 	}
 	return *app.Instances, nil
 }
 
-// Increase (scale up) the number of resources in the inventory
-func (m *MarathonInventory) Increase() error {
-	return m.Scale(+1)
+// Increase (scale up) the number of resources in the inventory by amount
+func (m *MarathonInventory) Increase(ctx context.Context, amount int) error {
+	return m.Scale(ctx, +amount)
 }
 
-// Decrease (scale down) the number of resources in the inventory
-func (m *MarathonInventory) Decrease() error {
-	return m.Scale(-1)
+// Decrease (scale down) the number of resources in the inventory by amount
+func (m *MarathonInventory) Decrease(ctx context.Context, amount int) error {
+	return m.Scale(ctx, -amount)
 }
 
 // Scale attempts to increase the number of instances by the amount specified
-func (m *MarathonInventory) Scale(amount int) error {
+func (m *MarathonInventory) Scale(ctx context.Context, amount int) error {
 	// Check inventory status before trying to scale anything
 	var e error
-	app, err := m.GetApplication()
+	app, err := m.GetApplication(ctx)
 	if err != nil {
 		return err
 	}
-	currentTotal, err := m.Total()
+	currentTotal, err := m.Total(ctx)
 	if err != nil {
 		return err
 	}
@@ -80,7 +89,7 @@ func (m *MarathonInventory) Scale(amount int) error {
 	if m.Config.IsSet("maximum_instances") && currentTotal+amount > m.Config.GetInt("maximum_instances") {
 		return errors.New("Won't scale above the maximum instances specified in config")
 	}
-	status, err := m.Status()
+	status, err := m.Status(ctx)
 	if err != nil {
 		return err
 	}
@@ -90,6 +99,9 @@ func (m *MarathonInventory) Scale(amount int) error {
 	case FAILED:
 		e = errors.New("Won't scale application while something seems to be in a failed state")
 	case OK:
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if _, err := m.Client.ScaleApplicationInstances(app.ID, currentTotal+amount, false); err != nil {
 			return err
 		}
@@ -104,8 +116,8 @@ func (m *MarathonInventory) Scale(amount int) error {
 }
 
 // Status returns OK if the inventory is ready to be scaled, UPDATING if an update is in progress, or FAILED
-func (m *MarathonInventory) Status() (Status, error) {
-	app, err := m.GetApplication()
+func (m *MarathonInventory) Status(ctx context.Context) (Status, error) {
+	app, err := m.GetApplication(ctx)
 	if err != nil {
 		return FAILED, err
 	}
@@ -120,7 +132,10 @@ func (m *MarathonInventory) Status() (Status, error) {
 }
 
 // GetApplication returns the marathon.Application for the current application being managed
-func (m *MarathonInventory) GetApplication() (*marathon.Application, error) {
+func (m *MarathonInventory) GetApplication(ctx context.Context) (*marathon.Application, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	name := m.Config.GetString("app")
 	app, err := m.Client.ApplicationBy(name, &marathon.GetAppOpts{})
 	if err != nil || app == nil {
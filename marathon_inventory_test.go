@@ -1,6 +1,7 @@
 package alice_test
 
 import (
+	"context"
 	"github.com/Sirupsen/logrus"
 	"github.com/gambol99/go-marathon"
 	"github.com/spf13/viper"
@@ -49,7 +50,7 @@ func setupMarathonInventoryTest() {
 
 func TestMarathonInventory_Total(t *testing.T) {
 	setupMarathonInventoryTest()
-	total, _ := marathonInv.Total()
+	total, _ := marathonInv.Total(ctx)
 	assert.Equal(t, total, 1)
 }
 
@@ -57,7 +58,7 @@ func TestMarathonInventory_Scale(t *testing.T) {
 	setupMarathonInventoryTest()
 	deployment := marathon.DeploymentID{}
 	mockClient.On("ScaleApplicationInstances").Return(deployment, nil)
-	err := marathonInv.Scale(+1)
+	err := marathonInv.Scale(ctx, +1)
 	assert.NoError(t, err)
 }
 
@@ -65,25 +66,32 @@ func TestMarathonInventory_Increase(t *testing.T) {
 	setupMarathonInventoryTest()
 	deployment := marathon.DeploymentID{}
 	mockClient.On("ScaleApplicationInstances").Return(deployment, nil)
-	assert.NoError(t, marathonInv.Increase())
+	assert.NoError(t, marathonInv.Increase(ctx, 1))
 
 	marathonInv.Config.Set("maximum_instances", 1)
-	assert.Error(t, marathonInv.Increase())
+	assert.Error(t, marathonInv.Increase(ctx, 1))
 }
 
 func TestMarathonInventory_Decrease(t *testing.T) {
 	setupMarathonInventoryTest()
 	deployment := marathon.DeploymentID{}
 	mockClient.On("ScaleApplicationInstances").Return(deployment, nil)
-	assert.NoError(t, marathonInv.Decrease())
+	assert.NoError(t, marathonInv.Decrease(ctx, 1))
 
 	marathonInv.Config.Set("minimum_instances", 1)
-	assert.Error(t, marathonInv.Decrease())
+	assert.Error(t, marathonInv.Decrease(ctx, 1))
+}
+
+func TestMarathonInventory_ScaleCancelled(t *testing.T) {
+	setupMarathonInventoryTest()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, marathonInv.Increase(cancelled, 1))
 }
 
 func TestMarathonInventory_Status(t *testing.T) {
 	setupMarathonInventoryTest()
-	s, _ := marathonInv.Status()
+	s, _ := marathonInv.Status(ctx)
 	assert.Equal(t, alice.OK, s)
 }
 
@@ -92,9 +100,9 @@ func TestMarathonInventory_SettleDownTime(t *testing.T) {
 	deployment := marathon.DeploymentID{}
 	mockClient.On("ScaleApplicationInstances").Return(deployment, nil)
 	marathonInv.Config.Set("settle_down_period", "5m")
-	assert.Nil(t, marathonInv.Increase())
-	s, _ := marathonInv.Status()
+	assert.Nil(t, marathonInv.Increase(ctx, 1))
+	s, _ := marathonInv.Status(ctx)
 	assert.Equal(t, alice.UPDATING, s)
-	assert.Error(t, marathonInv.Decrease())
-	assert.Error(t, marathonInv.Increase())
+	assert.Error(t, marathonInv.Decrease(ctx, 1))
+	assert.Error(t, marathonInv.Increase(ctx, 1))
 }
@@ -1,7 +1,10 @@
 package alice
 
 import (
+	"context"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/andygrunwald/megos"
@@ -55,28 +58,37 @@ type MesosClient interface {
 	DetermineLeader() (*megos.Pid, error)
 }
 
-const defaultMesosMaster = "http://mesos.service.consul:5050/state"
+// MesosConfig is the configuration of a MesosMonitor
+type MesosConfig struct {
+	Endpoint string        `config:"endpoint" default:"http://mesos.service.consul:5050/state" description:"URL of the Mesos master's state"`
+	Timeout  time.Duration `config:"timeout" default:"30s" description:"Give up on a call to Mesos after this long"`
+}
 
 // NewMesosMonitor creates a new Monitor
 func NewMesosMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, error) {
-	config.SetDefault("endpoint", defaultMesosMaster)
-	u, err := url.Parse(config.GetString("endpoint"))
+	var c MesosConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.Endpoint)
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't create mesos monitor")
 	}
-	mesos := megos.NewClient([]*url.URL{u}, nil)
+	mesos := megos.NewClient([]*url.URL{u}, &http.Client{Timeout: c.Timeout})
 	return &MesosMonitor{log: log, Client: mesos, config: config}, nil
 }
 
 // GetUpdatedMetrics returns MetricUpdates for each of the metrics requested
-func (m *MesosMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, error) {
+func (m *MesosMonitor) GetUpdatedMetrics(ctx context.Context, names []string) (*[]MetricUpdate, error) {
 	response := make([]MetricUpdate, len(names))
-	stats, err := m.Stats()
+	stats, err := m.Stats(ctx)
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	for i, name := range names {
 		response[i].Name = name
+		response[i].Timestamp = now
 		if val, ok := stats.Metrics[name]; ok {
 			response[i].CurrentReading = val
 		} else {
@@ -87,7 +99,10 @@ func (m *MesosMonitor) GetUpdatedMetrics(names []string) (*[]MetricUpdate, error
 }
 
 // Stats calculates interesting metrics about the Mesos cluster and the slaves
-func (m *MesosMonitor) Stats() (*MesosMonitorStats, error) {
+func (m *MesosMonitor) Stats(ctx context.Context) (*MesosMonitorStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	m.Client.DetermineLeader()
 	state, err := m.Client.GetStateFromLeader()
 	if err != nil {
@@ -59,7 +59,7 @@ func setupMesosMonitorTest() {
 
 func TestMesosMonitor_CalculatesStatistics(t *testing.T) {
 	setupMesosMonitorTest()
-	stats, err := mesosMon.Stats()
+	stats, err := mesosMon.Stats(ctx)
 	if err != nil {
 		t.Error(err)
 	}
@@ -76,6 +76,6 @@ func TestMesosMonitor_CalculatesStatistics(t *testing.T) {
 
 func TestMesosMonitor_GetUpdatedMetrics(t *testing.T) {
 	setupMesosMonitorTest()
-	_, err := mesosMon.GetUpdatedMetrics([]string{"invalid.metric.name"})
+	_, err := mesosMon.GetUpdatedMetrics(ctx, []string{"invalid.metric.name"})
 	assert.NotNil(t, err)
 }
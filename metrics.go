@@ -0,0 +1,62 @@
+package alice
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus metrics describing what each manager is doing. They are served on /metrics by the Server.
+var (
+	evaluationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alice",
+		Name:      "evaluations_total",
+		Help:      "Number of times a manager has evaluated its strategy.",
+	}, []string{"manager"})
+	evaluationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "alice",
+		Name:      "evaluation_duration_seconds",
+		Help:      "Time taken for a strategy to evaluate, including fetching metrics.",
+	}, []string{"manager"})
+	recommendationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alice",
+		Name:      "recommendations_total",
+		Help:      "Number of recommendations made by a manager's strategy, by direction (up, down or hold).",
+	}, []string{"manager", "direction"})
+	scaleAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alice",
+		Name:      "scale_attempts_total",
+		Help:      "Number of times a manager has asked its inventory to scale up or down.",
+	}, []string{"manager", "direction"})
+	scaleActionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alice",
+		Name:      "scale_actions_total",
+		Help:      "Outcome of scaling attempts, by result (succeeded or refused by the inventory).",
+	}, []string{"manager", "direction", "result"})
+	monitorErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alice",
+		Name:      "monitor_errors_total",
+		Help:      "Number of times a manager's monitor has failed to return metrics.",
+	}, []string{"manager"})
+	inventoryTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "alice",
+		Name:      "inventory_total",
+		Help:      "Total number of resources in a manager's inventory after its last run.",
+	}, []string{"manager"})
+	metricReading = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "alice",
+		Name:      "metric_reading",
+		Help:      "Last reading of each metric a manager's monitor returned.",
+	}, []string{"manager", "metric"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		evaluationsTotal,
+		evaluationDuration,
+		recommendationsTotal,
+		scaleAttemptsTotal,
+		scaleActionsTotal,
+		monitorErrorsTotal,
+		inventoryTotal,
+		metricReading,
+	)
+}
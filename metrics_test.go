@@ -0,0 +1,37 @@
+package alice_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	config := viper.New()
+	inv := MockInventory{}
+	inv.On("Total").Return(7, nil)
+	inv.On("Status").Return(alice.UPDATING, nil)
+	inv.On("Increase", 1).Return(errors.New("Won't scale servers while changes are in progress"))
+	str := MockStrategy{}
+	rec := alice.SCALEUP
+	str.On("Evaluate").Return(&rec, nil)
+	man := &alice.Manager{Name: "metrics_test", Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	man.Run(ctx)
+	man.Run(ctx)
+
+	server := alice.NewServer(func() []*alice.Manager { return []*alice.Manager{man} }, func(*alice.Manager) time.Duration { return time.Minute }, "", log)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `alice_evaluations_total{manager="metrics_test"} 2`)
+	assert.Contains(t, body, `alice_recommendations_total{direction="up",manager="metrics_test"} 2`)
+	assert.Contains(t, body, `alice_scale_attempts_total{direction="up",manager="metrics_test"} 2`)
+	assert.Contains(t, body, `alice_scale_actions_total{direction="up",manager="metrics_test",result="refused"} 2`)
+	assert.Contains(t, body, `alice_inventory_total{manager="metrics_test"} 7`)
+	assert.Contains(t, body, `alice_evaluation_duration_seconds_count{manager="metrics_test"} 2`)
+}
@@ -1,8 +1,13 @@
 package alice
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -10,15 +15,64 @@ import (
 
 // Monitor represents the generic Monitor interface. Monitors are a source of information that feed in to strategies.
 // Given a list of metrics (often in dot-notation for a lot of providers, eg sys.mem.free), a monitor must provide
-// a current reading for each one.
+// a current reading for each one. Monitors should pass the context on to any remote calls they make.
 type Monitor interface {
-	GetUpdatedMetrics([]string) (*[]MetricUpdate, error)
+	GetUpdatedMetrics(context.Context, []string) (*[]MetricUpdate, error)
 }
 
-// MetricUpdate stores the name of the metric requested and its current reading.
+// MetricUpdate stores the name of the metric requested and its current reading. Timestamp is when the reading was
+// observed, and Samples how many data points it was taken from if the monitor knows. A zero Timestamp means the
+// monitor doesn't know how old the reading is.
 type MetricUpdate struct {
-	Name           string
-	CurrentReading float64
+	Name           string    `json:"name"`
+	CurrentReading float64   `json:"current_reading"`
+	Timestamp      time.Time `json:"timestamp"`
+	Samples        int       `json:"samples,omitempty"`
+}
+
+// monitorRecorder wraps a Monitor and keeps the readings it returns and a count of its failures, so that a Manager
+// can report on them
+type monitorRecorder struct {
+	Monitor
+	mu       sync.Mutex
+	readings []MetricUpdate
+	failures int
+}
+
+// GetUpdatedMetrics returns MetricUpdates for each of the metrics requested
+func (r *monitorRecorder) GetUpdatedMetrics(ctx context.Context, names []string) (*[]MetricUpdate, error) {
+	updates, err := r.Monitor.GetUpdatedMetrics(ctx, names)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failures++
+	} else {
+		r.readings = append(r.readings, *updates...)
+	}
+	return updates, err
+}
+
+// take returns the readings and number of failures recorded since it was last called
+func (r *monitorRecorder) take() ([]MetricUpdate, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	readings, failures := r.readings, r.failures
+	r.readings, r.failures = nil, 0
+	return readings, failures
+}
+
+// stale describes the readings recorded since take was last called that were observed more than maxAge before now.
+// Readings without a timestamp are never stale.
+func (r *monitorRecorder) stale(now time.Time, maxAge time.Duration) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stale []string
+	for _, reading := range r.readings {
+		if age := now.Sub(reading.Timestamp); !reading.Timestamp.IsZero() && age > maxAge {
+			stale = append(stale, fmt.Sprintf("%s is %v old", reading.Name, age-age%time.Second))
+		}
+	}
+	return stale
 }
 
 // Create a hash for storing the names of registered monitors and their New() methods
@@ -40,8 +94,9 @@ func RegisterMonitor(name string, factory monitorFactoryFunc) {
 	monitors[name] = factory
 }
 
-// NewMonitor will take a generic block of configuration and read look for a 'name' key, and immediately pass
-// the block of config to the factory function that has been registered with that name.
+// NewMonitor will take a generic block of configuration and read look for a 'name' key, and pass the
+// block of config to the factory function that has been registered with that name. If the plugin has registered a
+// config struct with RegisterConfig, the config is checked against it first.
 func NewMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, error) {
 	// Find the correct monitor and return it
 	if !config.IsSet("name") {
@@ -51,11 +106,20 @@ func NewMonitor(config *viper.Viper, log *logrus.Entry) (Monitor, error) {
 	name := config.GetString("name")
 	newFunc, ok := monitors[name]
 	if !ok {
-		available := make([]string, len(monitors))
-		for m := range monitors {
-			available = append(available, m)
-		}
-		log.Fatalf("Invalid monitor name. Must be one of: %s", strings.Join(available, ", "))
+		return nil, fmt.Errorf("Invalid monitor name %s. Must be one of: %s", name, strings.Join(Monitors(), ", "))
+	}
+	if err := checkConfig(MonitorPlugin, name, config); err != nil {
+		return nil, err
 	}
 	return newFunc(config, log.WithField("monitor", name))
 }
+
+// Monitors returns the names of the registered monitors, sorted
+func Monitors() []string {
+	names := make([]string, 0, len(monitors))
+	for name := range monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
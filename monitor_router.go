@@ -0,0 +1,176 @@
+package alice
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// MonitorRouter is a Monitor that fetches metrics from several named monitors, so that a manager's strategy can use
+// metrics from more than one source. A metric is fetched from:
+//
+//   - the monitor it names, if it's written as <monitor name>:<metric name>;
+//   - otherwise the monitor with the longest of its 'prefixes' that the metric's name starts with;
+//   - otherwise the only monitor, if there is just one.
+//
+// The metrics for each monitor are fetched at the same time, and returned in the order requested, named as requested.
+type MonitorRouter struct {
+	Monitors map[string]Monitor
+	prefixes map[string]string // Metric name prefix to monitor name
+	log      *logrus.Entry
+}
+
+// MonitorRouterConfig is the routing configuration each of a MonitorRouter's monitors may have, alongside its usual
+// settings
+type MonitorRouterConfig struct {
+	Prefixes []string `config:"prefixes" description:"Fetch metrics whose names start with any of these from this monitor"`
+}
+
+// NewMonitorRouter creates a MonitorRouter from a block of configuration with a named block for each monitor
+func NewMonitorRouter(config *viper.Viper, log *logrus.Entry) (*MonitorRouter, error) {
+	var names []string
+	for name := range config.AllSettings() {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("No monitors defined")
+	}
+	sort.Strings(names)
+	r := &MonitorRouter{Monitors: map[string]Monitor{}, prefixes: map[string]string{}, log: log}
+	var errs ConfigErrors
+	for _, name := range names {
+		monitorConfig := config.Sub(name)
+		if monitorConfig == nil {
+			errs = append(errs, configErrorf(name, "Monitor has no configuration"))
+			continue
+		}
+		if strings.Contains(name, ":") {
+			errs = append(errs, configErrorf(name, "Monitor names can't contain ':'"))
+		}
+		var c MonitorRouterConfig
+		if err := LoadConfig(monitorConfig, &c); err != nil {
+			errs = append(errs, inConfig(name, err)...)
+			continue
+		}
+		for _, prefix := range c.Prefixes {
+			if other, ok := r.prefixes[prefix]; ok {
+				errs = append(errs, configErrorf(name+".prefixes", "Prefix %s is already used by %s", prefix, other))
+			}
+			r.prefixes[prefix] = name
+		}
+		mon, err := NewMonitor(monitorConfig, log.WithField("monitors", name))
+		if err != nil {
+			errs = append(errs, inConfig(name, err)...)
+			continue
+		}
+		r.Monitors[name] = mon
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return r, nil
+}
+
+// Validate asks each monitor to validate itself
+func (r *MonitorRouter) Validate() []error {
+	var errs []error
+	for _, name := range r.names() {
+		if v, ok := r.Monitors[name].(Validator); ok {
+			errs = append(errs, inConfig(name, v.Validate()...)...)
+		}
+	}
+	return errs
+}
+
+// names returns the names of the monitors, sorted
+func (r *MonitorRouter) names() []string {
+	names := make([]string, 0, len(r.Monitors))
+	for name := range r.Monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// route returns the monitor a metric should be fetched from, and its name as that monitor knows it
+func (r *MonitorRouter) route(metric string) (string, string, error) {
+	if i := strings.Index(metric, ":"); i >= 0 {
+		if _, ok := r.Monitors[metric[:i]]; ok {
+			return metric[:i], metric[i+1:], nil
+		}
+	}
+	longest := ""
+	for prefix := range r.prefixes {
+		if strings.HasPrefix(metric, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest != "" {
+		return r.prefixes[longest], metric, nil
+	}
+	if len(r.Monitors) == 1 {
+		for name := range r.Monitors {
+			return name, metric, nil
+		}
+	}
+	return "", "", errors.Errorf("No monitor for metric %s", metric)
+}
+
+// GetUpdatedMetrics returns MetricUpdates for each of the metrics requested, fetching them from every monitor needed
+// at the same time. If any monitor fails, so does the whole request.
+func (r *MonitorRouter) GetUpdatedMetrics(ctx context.Context, names []string) (*[]MetricUpdate, error) {
+	type request struct {
+		metrics []string
+		indexes []int
+	}
+	requests := map[string]*request{}
+	for i, name := range names {
+		monitor, metric, err := r.route(name)
+		if err != nil {
+			return nil, err
+		}
+		if requests[monitor] == nil {
+			requests[monitor] = &request{}
+		}
+		requests[monitor].metrics = append(requests[monitor].metrics, metric)
+		requests[monitor].indexes = append(requests[monitor].indexes, i)
+	}
+
+	response := make([]MetricUpdate, len(names))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failures []string
+	for monitor, req := range requests {
+		wg.Add(1)
+		go func(monitor string, req *request) {
+			defer wg.Done()
+			updates, err := r.Monitors[monitor].GetUpdatedMetrics(ctx, req.metrics)
+			if err == nil && len(*updates) != len(req.metrics) {
+				err = errors.Errorf("Asked for %d metrics but got %d", len(req.metrics), len(*updates))
+			}
+			if err != nil {
+				r.log.Warnf("Monitor %s failed: %s", monitor, err.Error())
+				mu.Lock()
+				failures = append(failures, monitor+": "+err.Error())
+				mu.Unlock()
+				return
+			}
+			// Each request writes to its own indexes, so they don't need the lock
+			for j, update := range *updates {
+				update.Name = names[req.indexes[j]]
+				response[req.indexes[j]] = update
+			}
+		}(monitor, req)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return nil, errors.Errorf("Error getting metrics from %s", strings.Join(failures, "; "))
+	}
+	return &response, nil
+}
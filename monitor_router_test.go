@@ -0,0 +1,116 @@
+package alice_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// EchoMonitor reads every metric as its configured 'reading', observed at its configured 'timestamp', and remembers
+// which metrics it was asked for
+type EchoMonitor struct {
+	reading float64
+	at      time.Time
+	fail    bool
+	mu      sync.Mutex
+	asked   []string
+}
+
+func (e *EchoMonitor) GetUpdatedMetrics(_ context.Context, names []string) (*[]alice.MetricUpdate, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.asked = append(e.asked, names...)
+	if e.fail {
+		return nil, errors.New("Unavailable")
+	}
+	updates := make([]alice.MetricUpdate, len(names))
+	for i, name := range names {
+		updates[i] = alice.MetricUpdate{Name: name, CurrentReading: e.reading, Timestamp: e.at}
+	}
+	return &updates, nil
+}
+
+func NewEchoMonitor(config *viper.Viper, _ *logrus.Entry) (alice.Monitor, error) {
+	return &EchoMonitor{reading: config.GetFloat64("reading"), at: config.GetTime("timestamp")}, nil
+}
+
+func init() {
+	alice.RegisterMonitor("echo", NewEchoMonitor)
+}
+
+func setupMonitorRouterTest() *alice.MonitorRouter {
+	config := viper.New()
+	config.Set("mesos", map[string]interface{}{"name": "echo", "reading": 1, "prefixes": []string{"mesos."}})
+	config.Set("datadog", map[string]interface{}{"name": "echo", "reading": 2, "prefixes": []string{"app.", "app.mesos."}})
+	router, err := alice.NewMonitorRouter(config, log)
+	if err != nil {
+		panic(err)
+	}
+	return router
+}
+
+func TestMonitorRouter_GetUpdatedMetrics(t *testing.T) {
+	router := setupMonitorRouterTest()
+	updates, err := router.GetUpdatedMetrics(ctx, []string{"mesos.cpu", "datadog:latency", "app.mesos.users", "mesos:app.queue"})
+	assert.NoError(t, err)
+	assert.Equal(t, []alice.MetricUpdate{
+		{Name: "mesos.cpu", CurrentReading: 1},
+		{Name: "datadog:latency", CurrentReading: 2},
+		{Name: "app.mesos.users", CurrentReading: 2},
+		{Name: "mesos:app.queue", CurrentReading: 1},
+	}, *updates)
+	assert.Equal(t, []string{"mesos.cpu", "app.queue"}, router.Monitors["mesos"].(*EchoMonitor).asked)
+	assert.Equal(t, []string{"latency", "app.mesos.users"}, router.Monitors["datadog"].(*EchoMonitor).asked)
+
+	_, err = router.GetUpdatedMetrics(ctx, []string{"unrouted"})
+	assert.EqualError(t, err, "No monitor for metric unrouted")
+}
+
+func TestMonitorRouter_Failure(t *testing.T) {
+	router := setupMonitorRouterTest()
+	router.Monitors["datadog"].(*EchoMonitor).fail = true
+	_, err := router.GetUpdatedMetrics(ctx, []string{"mesos.cpu", "app.users"})
+	assert.EqualError(t, err, "Error getting metrics from datadog: Unavailable")
+}
+
+func TestNewMonitorRouter(t *testing.T) {
+	config := viper.New()
+	config.Set("only", map[string]interface{}{"name": "echo", "reading": 3})
+	router, err := alice.NewMonitorRouter(config, log)
+	assert.NoError(t, err)
+	updates, err := router.GetUpdatedMetrics(ctx, []string{"anything"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, (*updates)[0].CurrentReading)
+
+	config.Set("other", map[string]interface{}{"name": "unknown"})
+	config.Set("bad:name", map[string]interface{}{"name": "echo"})
+	_, err = alice.NewMonitorRouter(config, log)
+	assert.Len(t, err, 2)
+
+	_, err = alice.NewMonitorRouter(viper.New(), log)
+	assert.Error(t, err)
+}
+
+func TestManager_NewWithMonitors(t *testing.T) {
+	config := viper.New()
+	config.Set("inventory", map[string]interface{}{"name": "mock"})
+	config.Set("strategy", map[string]interface{}{"name": "mock"})
+	config.Set("monitors", map[string]interface{}{
+		"mesos":   map[string]interface{}{"name": "echo"},
+		"datadog": map[string]interface{}{"name": "echo"},
+	})
+	mgr, err := alice.New("web", config, log)
+	assert.NoError(t, err)
+	assert.NotNil(t, mgr.Monitor)
+
+	config.Set("monitor", map[string]interface{}{"name": "echo"})
+	_, err = alice.New("web", config, log)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package alice_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Sirupsen/logrus"
@@ -14,7 +15,7 @@ type MockMonitor struct {
 	mock.Mock
 }
 
-func (m *MockMonitor) GetUpdatedMetrics(names []string) (*[]alice.MetricUpdate, error) {
+func (m *MockMonitor) GetUpdatedMetrics(_ context.Context, names []string) (*[]alice.MetricUpdate, error) {
 	args := m.Mock.Called()
 	return args.Get(0).(*[]alice.MetricUpdate), args.Error(1)
 }
@@ -35,4 +36,5 @@ func TestNewMonitor(t *testing.T) {
 	setupMonitorTest()
 	i, _ := alice.NewMonitor(config, log)
 	assert.IsType(t, &MockMonitor{}, i)
+	assert.Contains(t, alice.Monitors(), "mock")
 }
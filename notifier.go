@@ -0,0 +1,331 @@
+package alice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Notifier represents the generic notifier interface. A notifier tells people about things that happen to managers,
+// such as scaling actions, wherever they will see them. Notifiers should pass the context on to any remote calls they
+// make.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// EventDefaulter is implemented by notifiers that only want some types of event unless they're configured with events
+// of their own
+type EventDefaulter interface {
+	DefaultEvents() []EventType
+}
+
+// EventType is the kind of thing that happened to a manager
+type EventType string
+
+const (
+	// EventScaledUp - the inventory was scaled up
+	EventScaledUp EventType = "scaled_up"
+	// EventScaledDown - the inventory was scaled down
+	EventScaledDown EventType = "scaled_down"
+	// EventAdvisory - the inventory would have been scaled, but scaling in that direction is disabled
+	EventAdvisory EventType = "advisory"
+	// EventRefused - the inventory refused to scale
+	EventRefused EventType = "refused"
+	// EventInventoryFailed - the inventory's status has become FAILED
+	EventInventoryFailed EventType = "inventory_failed"
+	// EventMonitorFailing - the monitor has started failing to provide metrics
+	EventMonitorFailing EventType = "monitor_failing"
+	// EventApprovalNeeded - the inventory will be scaled once someone approves it
+	EventApprovalNeeded EventType = "approval_needed"
+	// EventMetricsStale - the manager has started holding because its metrics are older than max_metric_age
+	EventMetricsStale EventType = "metrics_stale"
+	// EventInventoryRecovered - the inventory's status is no longer FAILED
+	EventInventoryRecovered EventType = "inventory_recovered"
+	// EventMonitorRecovered - the monitor is providing metrics again
+	EventMonitorRecovered EventType = "monitor_recovered"
+)
+
+// EventTypes are all the types of event, in the order they're described
+var EventTypes = []EventType{EventScaledUp, EventScaledDown, EventAdvisory, EventRefused, EventInventoryFailed, EventMonitorFailing, EventApprovalNeeded, EventMetricsStale, EventInventoryRecovered, EventMonitorRecovered}
+
+// Event describes something that happened to a manager during a run. Message is the event written for people to
+// read, and is filled in before the event is passed to a Notifier.
+type Event struct {
+	Type        EventType      `json:"type"`
+	Manager     string         `json:"manager"`
+	Time        time.Time      `json:"time"`
+	Inventory   string         `json:"inventory"`
+	Monitor     string         `json:"monitor"`
+	Strategy    string         `json:"strategy"`
+	Direction   string         `json:"direction,omitempty"`
+	Step        int            `json:"step,omitempty"`
+	TotalBefore *int           `json:"total_before,omitempty"`
+	TotalAfter  *int           `json:"total_after,omitempty"`
+	Reason      string         `json:"reason,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Pending     *PendingAction `json:"pending,omitempty"`    // The action waiting for approval
+	Evaluation  *Evaluation    `json:"evaluation,omitempty"` // Why the strategy recommended scaling
+	Message     string         `json:"message"`
+}
+
+// defaultTemplates are the messages used for each type of event, unless a notifier is configured with its own
+var defaultTemplates = map[EventType]string{
+	EventScaledUp:           "Scaled {{.Manager}} up by {{.Step}}{{with .TotalAfter}} to {{.}}{{end}}{{with .Reason}} ({{.}}){{end}}{{with .Evaluation}}{{with .String}} because {{.}}{{end}}{{end}}",
+	EventScaledDown:         "Scaled {{.Manager}} down by {{.Step}}{{with .TotalAfter}} to {{.}}{{end}}{{with .Reason}} ({{.}}){{end}}{{with .Evaluation}}{{with .String}} because {{.}}{{end}}{{end}}",
+	EventAdvisory:           "Would have scaled {{.Manager}} {{.Direction}} by {{.Step}}, but didn't: {{.Reason}}",
+	EventRefused:            "Couldn't scale {{.Manager}} {{.Direction}} by {{.Step}}: {{.Reason}}",
+	EventInventoryFailed:    "The {{.Inventory}} inventory of {{.Manager}} has FAILED",
+	EventMonitorFailing:     "The {{.Monitor}} monitor of {{.Manager}} is failing{{with .Error}}: {{.}}{{end}}",
+	EventMetricsStale:       "Not scaling {{.Manager}} {{.Direction}} by {{.Step}} on stale metrics: {{.Reason}}",
+	EventInventoryRecovered: "The {{.Inventory}} inventory of {{.Manager}} is no longer FAILED",
+	EventMonitorRecovered:   "The {{.Monitor}} monitor of {{.Manager}} has recovered",
+	EventApprovalNeeded:     `Scaling {{.Manager}} {{.Direction}} by {{.Step}} needs approval before {{.Pending.Until.Format "2006-01-02 15:04 MST"}}: run 'alice approve {{.Manager}}'`,
+}
+
+// Create a hash for storing the names of registered notifiers and their New() methods
+// eg {'foo': foo.New(), 'bar': bar.New(), 'baz': baz.New()}
+type notifierFactoryFunc func(config *viper.Viper, log *logrus.Entry) (Notifier, error)
+
+var notifiers = make(map[string]notifierFactoryFunc)
+
+// RegisterNotifier allows a new notifier type to be registered with a string name. This name is used to match
+// configuration to the correct NewFooNotifier function that can read it.
+func RegisterNotifier(name string, factory notifierFactoryFunc) {
+	if factory == nil {
+		logrus.Panicf("New() for %s does not exist.", name)
+	}
+	_, registered := notifiers[name]
+	if registered {
+		logrus.Errorf("New() for %s already registered. Ignoring.", name)
+	}
+	notifiers[name] = factory
+}
+
+// NewNotifier will take a generic block of configuration and read look for a 'name' key, and pass the
+// block of config to the factory function that has been registered with that name. If the plugin has registered a
+// config struct with RegisterConfig, the config is checked against it first.
+func NewNotifier(config *viper.Viper, log *logrus.Entry) (Notifier, error) {
+	if !config.IsSet("name") {
+		return nil, errors.New("No notifier name provided")
+	}
+	name := config.GetString("name")
+	newFunc, ok := notifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("Invalid notifier name %s. Must be one of: %s", name, strings.Join(Notifiers(), ", "))
+	}
+	if err := checkConfig(NotifierPlugin, name, config); err != nil {
+		return nil, err
+	}
+	return newFunc(config, log.WithField("notifier", name))
+}
+
+// Notifiers returns the names of the registered notifiers, sorted
+func Notifiers() []string {
+	names := make([]string, 0, len(notifiers))
+	for name := range notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Notifications is a Notifier that passes events on to several named notifiers, each of which can choose which events
+// and managers it hears about, and how its messages are written
+type Notifications struct {
+	Notifiers map[string]Notifier
+	routes    map[string]*notificationRoute
+}
+
+// NotificationConfig is the routing configuration each of the notifiers used by Notifications may have, alongside its
+// usual settings. Templates are Go templates, given the Event.
+type NotificationConfig struct {
+	Events    []string          `config:"events" description:"Only send these types of event (default all of them): scaled_up, scaled_down, advisory, refused, inventory_failed, monitor_failing, approval_needed, metrics_stale, inventory_recovered or monitor_recovered"`
+	Managers  []string          `config:"managers" description:"Only send events from these managers (default all of them)"`
+	Template  string            `config:"template" description:"Template for the message of every event"`
+	Templates map[string]string `config:"templates" description:"Templates for the messages of particular types of event, overriding template"`
+}
+
+// notificationRoute is which events a notifier hears about, and how their messages are written
+type notificationRoute struct {
+	events    map[EventType]bool // Every event if empty
+	managers  map[string]bool    // Every manager if empty
+	templates map[EventType]*template.Template
+}
+
+// NewNotifications creates Notifications from a block of configuration with a named block for each notifier
+func NewNotifications(config *viper.Viper, log *logrus.Entry) (*Notifications, error) {
+	var names []string
+	for name := range config.AllSettings() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	n := &Notifications{Notifiers: map[string]Notifier{}, routes: map[string]*notificationRoute{}}
+	var errs ConfigErrors
+	for _, name := range names {
+		notifierConfig := config.Sub(name)
+		if notifierConfig == nil {
+			errs = append(errs, configErrorf(name, "Notifier has no configuration"))
+			continue
+		}
+		route, routeErr := newNotificationRoute(notifierConfig)
+		if routeErr != nil {
+			errs = append(errs, inConfig(name, routeErr)...)
+		}
+		notifier, err := NewNotifier(notifierConfig, log.WithField("notifiers", name))
+		if err != nil {
+			errs = append(errs, inConfig(name, err)...)
+		}
+		if routeErr == nil && err == nil {
+			if d, ok := notifier.(EventDefaulter); ok && len(route.events) == 0 {
+				for _, t := range d.DefaultEvents() {
+					route.events[t] = true
+				}
+			}
+			n.Notifiers[name], n.routes[name] = notifier, route
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return n, nil
+}
+
+// newNotificationRoute reads a notifier's routing configuration, checking its event types and templates
+func newNotificationRoute(config *viper.Viper) (*notificationRoute, error) {
+	var c NotificationConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	route := &notificationRoute{events: map[EventType]bool{}, managers: map[string]bool{}, templates: map[EventType]*template.Template{}}
+	var errs ConfigErrors
+	known := map[EventType]bool{}
+	for _, t := range EventTypes {
+		known[t] = true
+	}
+	for _, name := range c.Events {
+		if !known[EventType(name)] {
+			errs = append(errs, configErrorf("events", "Unknown event type: %s", name))
+		}
+		route.events[EventType(name)] = true
+	}
+	for _, name := range c.Managers {
+		route.managers[name] = true
+	}
+	for name := range c.Templates {
+		if !known[EventType(name)] {
+			errs = append(errs, configErrorf("templates."+name, "Unknown event type: %s", name))
+		}
+	}
+	for _, t := range EventTypes {
+		key, text := "templates."+string(t), c.Templates[string(t)]
+		if text == "" {
+			key, text = "template", c.Template
+		}
+		if text == "" {
+			key, text = "", defaultTemplates[t]
+		}
+		tmpl, err := template.New(string(t)).Option("missingkey=error").Parse(text)
+		if err != nil {
+			errs = append(errs, configErrorf(key, "Invalid template: %s", err.Error()))
+			continue
+		}
+		route.templates[t] = tmpl
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return route, nil
+}
+
+// Validate asks each notifier to validate itself
+func (n *Notifications) Validate() []error {
+	var errs []error
+	for _, name := range n.names() {
+		if v, ok := n.Notifiers[name].(Validator); ok {
+			errs = append(errs, inConfig(name, v.Validate()...)...)
+		}
+	}
+	return errs
+}
+
+// Notify sends the event to every notifier that wants it, at the same time, with the message written the way each
+// one asks. Every notifier is tried, even if some of them fail.
+func (n *Notifications) Notify(ctx context.Context, event Event) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failures []string
+	for _, name := range n.names() {
+		route := n.routes[name]
+		if (len(route.events) > 0 && !route.events[event.Type]) || (len(route.managers) > 0 && !route.managers[event.Manager]) {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, route *notificationRoute) {
+			defer wg.Done()
+			var buf bytes.Buffer
+			e := event
+			err := route.templates[event.Type].Execute(&buf, e)
+			if err == nil {
+				e.Message = buf.String()
+				err = n.Notifiers[name].Notify(ctx, e)
+			}
+			if err != nil {
+				mu.Lock()
+				failures = append(failures, name+": "+err.Error())
+				mu.Unlock()
+			}
+		}(name, route)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.Errorf("Error sending notifications to %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// names returns the names of the notifiers, sorted
+func (n *Notifications) names() []string {
+	names := make([]string, 0, len(n.Notifiers))
+	for name := range n.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// postJSON sends body to url as JSON, with any extra headers, failing unless the response is a success
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("Unexpected response: %s", resp.Status)
+	}
+	return nil
+}
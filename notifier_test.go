@@ -0,0 +1,216 @@
+package alice_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, event alice.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+// events returns the events the notifier has been sent
+func (m *MockNotifier) events() []alice.Event {
+	var events []alice.Event
+	for _, call := range m.Calls {
+		events = append(events, call.Arguments.Get(0).(alice.Event))
+	}
+	return events
+}
+
+func NewMockNotifier(config *viper.Viper, log *logrus.Entry) (alice.Notifier, error) {
+	return &MockNotifier{}, nil
+}
+
+func init() {
+	alice.RegisterNotifier("mock", NewMockNotifier)
+}
+
+func setupNotificationsTest() (*alice.Notifications, *MockNotifier, *MockNotifier) {
+	config := viper.New()
+	config.Set("ops", map[string]interface{}{
+		"name":     "mock",
+		"events":   []string{"scaled_up", "refused"},
+		"template": "{{.Manager}}: {{.Type}}",
+	})
+	config.Set("web", map[string]interface{}{
+		"name":      "mock",
+		"managers":  []string{"web"},
+		"templates": map[string]interface{}{"scaled_up": "Added {{.Step}}"},
+	})
+	notifications, err := alice.NewNotifications(config, log)
+	if err != nil {
+		panic(err)
+	}
+	ops, web := notifications.Notifiers["ops"].(*MockNotifier), notifications.Notifiers["web"].(*MockNotifier)
+	ops.On("Notify", mock.Anything).Return(nil)
+	web.On("Notify", mock.Anything).Return(nil)
+	return notifications, ops, web
+}
+
+func TestNotifications_Notify(t *testing.T) {
+	notifications, ops, web := setupNotificationsTest()
+	total := 12
+	assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: alice.EventScaledUp, Manager: "web", Step: 2}))
+	assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: alice.EventRefused, Manager: "api"}))
+	assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: alice.EventScaledDown, Manager: "web", Step: 1, TotalAfter: &total}))
+
+	until := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	pending := alice.PendingAction{ID: 1, Direction: "up", Step: 2, Until: until}
+	assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: alice.EventApprovalNeeded, Manager: "web", Direction: "up", Step: 2, Pending: &pending}))
+
+	evaluation := alice.Evaluation{Metrics: []alice.MetricEvaluation{{Name: "cpu", Reason: "cpu is 5, below the min of 10"}}, Deciding: "cpu"}
+	assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: alice.EventScaledDown, Manager: "web", Step: 1, TotalAfter: &total, Evaluation: &evaluation}))
+
+	assert.Len(t, ops.events(), 2)
+	assert.Equal(t, "web: scaled_up", ops.events()[0].Message)
+	assert.Equal(t, "api: refused", ops.events()[1].Message)
+	assert.Len(t, web.events(), 4)
+	assert.Equal(t, "Added 2", web.events()[0].Message)
+	assert.Equal(t, "Scaled web down by 1 to 12", web.events()[1].Message)
+	assert.Equal(t, "Scaling web up by 2 needs approval before 2017-01-01 10:00 UTC: run 'alice approve web'", web.events()[2].Message)
+	assert.Equal(t, "Scaled web down by 1 to 12 because cpu is 5, below the min of 10", web.events()[3].Message)
+}
+
+func TestNotifications_Failure(t *testing.T) {
+	notifications, ops, web := setupNotificationsTest()
+	ops.ExpectedCalls = nil
+	ops.On("Notify", mock.Anything).Return(errors.New("Unavailable"))
+	err := notifications.Notify(ctx, alice.Event{Type: alice.EventScaledUp, Manager: "web"})
+	assert.EqualError(t, err, "Error sending notifications to ops: Unavailable")
+	assert.Len(t, web.events(), 1)
+}
+
+func TestNewNotifications(t *testing.T) {
+	config := viper.New()
+	config.Set("ops", map[string]interface{}{
+		"name":      "mock",
+		"events":    []string{"scaled_sideways"},
+		"templates": map[string]interface{}{"refused": "{{.Manager"},
+	})
+	config.Set("other", map[string]interface{}{"name": "unknown"})
+	_, err := alice.NewNotifications(config, log)
+	assert.Len(t, err, 3)
+	assert.Contains(t, err.Error(), "ops.events: Unknown event type: scaled_sideways")
+	assert.Contains(t, err.Error(), "ops.templates.refused: Invalid template")
+
+	assert.Contains(t, alice.Notifiers(), "mock")
+}
+
+func TestNotificationConfig_Events(t *testing.T) {
+	// The description of the events setting names every type of event
+	for _, field := range alice.ConfigFields(alice.NotificationConfig{}) {
+		if field.Key == "events" {
+			for _, eventType := range alice.EventTypes {
+				assert.Contains(t, field.Description, string(eventType))
+			}
+		}
+	}
+}
+
+func TestManager_Notify(t *testing.T) {
+	setupManagerTest()
+	config.Set("scale_up", true)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	notifier := MockNotifier{}
+	elector := MockElector{}
+	notifier.On("Notify", mock.Anything).Return(nil)
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.FAILED, nil)
+	man = alice.Manager{Name: "web", Strategy: &str, Inventory: &inv, Logger: log, Config: config, Notifier: &notifier, Elector: &elector}
+
+	recommendation = alice.SCALEUP
+	str.On("Evaluate").Return(&recommendation, nil)
+	inv.On("Increase", 1).Return(errors.New("Too big")).Once()
+	elector.On("IsLeader").Return(true)
+	man.Run(ctx)
+	events := notifier.events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, alice.EventRefused, events[0].Type)
+	assert.Equal(t, "up", events[0].Direction)
+	assert.Equal(t, "Too big", events[0].Reason)
+	assert.Equal(t, alice.EventInventoryFailed, events[1].Type)
+
+	// The inventory is only reported as failed when it starts failing
+	inv.On("Increase", 1).Return(nil).Once()
+	man.Run(ctx)
+	events = notifier.events()
+	assert.Len(t, events, 3)
+	assert.Equal(t, alice.EventScaledUp, events[2].Type)
+	assert.Equal(t, 10, *events[2].TotalAfter)
+	assert.Nil(t, events[2].Evaluation)
+
+	// Only the leader sends notifications
+	elector.ExpectedCalls = nil
+	elector.On("IsLeader").Return(false)
+	man.Run(ctx)
+	assert.Len(t, notifier.events(), 3)
+}
+
+func TestManager_NotifyPinned(t *testing.T) {
+	man, inv, _, _ := setupOverrideTest()
+	str := MockExplainedStrategy{}
+	notifier := MockNotifier{}
+	man.Strategy, man.Notifier = &str, &notifier
+	notifier.On("Notify", mock.Anything).Return(nil)
+	inv.On("Status").Return(alice.OK, nil)
+	inv.On("Total").Return(10, nil)
+	inv.On("Increase", 2).Return(nil)
+	down := alice.SCALEDOWN
+	str.On("Evaluate").Return(&down, nil)
+	str.On("Explain").Return(&alice.Evaluation{Metrics: []alice.MetricEvaluation{{Name: "cpu", Reason: "cpu is 5, below the min of 10"}}, Deciding: "cpu"})
+
+	// The strategy's evaluation isn't why a pinned manager scaled, so it isn't sent
+	assert.NoError(t, man.Pin(12, time.Hour, "Sale"))
+	assert.NoError(t, man.Run(ctx))
+	events := notifier.events()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, alice.EventScaledUp, events[0].Type)
+		assert.Equal(t, 2, events[0].Step)
+		assert.Nil(t, events[0].Evaluation)
+	}
+}
+
+func TestManager_NotifyRecovered(t *testing.T) {
+	setupManagerTest()
+	inv := MockInventory{}
+	str := MockStrategy{}
+	notifier := MockNotifier{}
+	notifier.On("Notify", mock.Anything).Return(nil)
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.FAILED, nil).Once()
+	inv.On("Status").Return(alice.OK, errors.New("Can't tell")).Once()
+	inv.On("Status").Return(alice.OK, nil)
+	man = alice.Manager{Name: "web", Strategy: &str, Inventory: &inv, Logger: log, Config: config, Notifier: &notifier}
+	recommendation = alice.HOLD
+	str.On("Evaluate").Return(&recommendation, nil)
+
+	man.Run(ctx)
+	assert.Len(t, notifier.events(), 1)
+	assert.Equal(t, alice.EventInventoryFailed, notifier.events()[0].Type)
+
+	// Not knowing the inventory's status isn't a recovery
+	man.Run(ctx)
+	assert.Len(t, notifier.events(), 1)
+
+	man.Run(ctx)
+	if assert.Len(t, notifier.events(), 2) {
+		assert.Equal(t, alice.EventInventoryRecovered, notifier.events()[1].Type)
+	}
+	man.Run(ctx)
+	assert.Len(t, notifier.events(), 2)
+}
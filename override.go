@@ -0,0 +1,146 @@
+package alice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OverrideKind is the way an operator has taken control of a Manager
+type OverrideKind string
+
+const (
+	// OverridePause - keep evaluating the strategy, but don't act on its recommendations
+	OverridePause OverrideKind = "pause"
+	// OverridePin - ignore the strategy and keep the inventory at a fixed total
+	OverridePin OverrideKind = "pin"
+)
+
+// Override temporarily takes control of a Manager away from its strategy, until it expires or the Manager is resumed
+type Override struct {
+	Kind   OverrideKind `json:"kind"`
+	Total  int          `json:"total,omitempty"` // The pinned total
+	Since  time.Time    `json:"since"`
+	Until  *time.Time   `json:"until,omitempty"` // Never expires if nil
+	Reason string       `json:"reason,omitempty"`
+}
+
+func (o Override) String() string {
+	description := "Paused"
+	if o.Kind == OverridePin {
+		description = fmt.Sprintf("Pinned at %d", o.Total)
+	}
+	if o.Until != nil {
+		description += " until " + o.Until.Format(time.RFC3339)
+	}
+	if o.Reason != "" {
+		description += ": " + o.Reason
+	}
+	return description
+}
+
+// Pause stops the manager acting on recommendations, while still evaluating them, for the given duration. A duration
+// of 0 pauses it until it is resumed.
+func (m *Manager) Pause(duration time.Duration, reason string) {
+	m.setOverride(&Override{Kind: OverridePause, Reason: reason}, duration)
+}
+
+// Pin keeps the inventory at total for the given duration, whatever the strategy recommends
+func (m *Manager) Pin(total int, duration time.Duration, reason string) error {
+	if total < 0 {
+		return errors.Errorf("Can't pin at a negative total of %d", total)
+	}
+	if duration <= 0 {
+		return errors.New("Must pin for a positive duration")
+	}
+	m.setOverride(&Override{Kind: OverridePin, Total: total, Reason: reason}, duration)
+	return nil
+}
+
+// Resume hands control back to the strategy, cancelling any override
+func (m *Manager) Resume() {
+	m.mu.Lock()
+	override := m.override
+	m.override = nil
+	m.mu.Unlock()
+	if override != nil {
+		m.Logger.Warnf("Resuming after being %s", override)
+	}
+}
+
+// Force scales the inventory straight away by amount, regardless of the strategy or any override. Positive amounts
+// scale up and negative ones scale down. Only the leader will scale.
+func (m *Manager) Force(ctx context.Context, amount int, reason string) error {
+	rec := Recommendation(amount)
+	if rec == HOLD {
+		return errors.New("Must force a non-zero amount")
+	}
+	m.running.Lock()
+	defer m.running.Unlock()
+	run := AuditRecord{Time: m.now(), Manager: m.Name, Action: ActionNone, Reason: "Forced: " + reason}
+	m.Logger.Warnf("Forcing scale %s by %d: %s", rec.direction(), rec.Step(), reason)
+	err := m.change(ctx, &run, rec.direction(), rec.Step())
+	if err == nil && run.Action == ActionScaled {
+		run.Action = ActionForced
+	} else if err == nil {
+		err = errors.New(run.Reason)
+	}
+	m.record(ctx, &run, err)
+	return err
+}
+
+// Override returns the override currently in force, if there is one
+func (m *Manager) Override() *Override {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.currentOverride()
+}
+
+// currentOverride returns the override in force, forgetting it if it has expired. m.mu must be held.
+func (m *Manager) currentOverride() *Override {
+	if m.override == nil {
+		return nil
+	}
+	if m.override.Until != nil && !m.now().Before(*m.override.Until) {
+		m.Logger.Warnf("No longer %s, the override has expired", m.override)
+		m.override = nil
+		return nil
+	}
+	override := *m.override
+	return &override
+}
+
+func (m *Manager) setOverride(override *Override, duration time.Duration) {
+	override.Since = m.now()
+	if duration > 0 {
+		until := override.Since.Add(duration)
+		override.Until = &until
+	}
+	m.mu.Lock()
+	m.override = override
+	m.mu.Unlock()
+	m.Logger.Warnf("%s", override)
+}
+
+// pin scales the inventory towards the total it has been pinned at
+func (m *Manager) pin(ctx context.Context, run *AuditRecord, override *Override) error {
+	total, err := m.Inventory.Total(ctx)
+	if err != nil {
+		run.Reason = "Can't get the inventory total to keep it pinned"
+		return err
+	}
+	rec := Recommendation(override.Total - total)
+	if rec == HOLD {
+		m.Logger.Infof("%s, doing nothing", override)
+		run.Action, run.Reason = ActionPinned, override.String()
+		return nil
+	}
+	run.Reason = override.String()
+	err = m.change(ctx, run, rec.direction(), rec.Step())
+	if err == nil && run.Action == ActionScaled {
+		run.Action = ActionPinned
+	}
+	return err
+}
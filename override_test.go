@@ -0,0 +1,108 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupOverrideTest() (*alice.Manager, *MockInventory, *MockStrategy, *time.Time) {
+	config := viper.New()
+	config.Set("scale_up", true)
+	config.Set("scale_down", true)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	now := time.Date(2017, 1, 1, 9, 0, 0, 0, time.UTC)
+	man := &alice.Manager{Name: "web", Strategy: &str, Inventory: &inv, Logger: log, Config: config,
+		Clock: func() time.Time { return now }}
+	return man, &inv, &str, &now
+}
+
+func TestManager_Pause(t *testing.T) {
+	man, inv, str, now := setupOverrideTest()
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	up := alice.SCALEUP
+	str.On("Evaluate").Return(&up, nil)
+
+	man.Pause(time.Hour, "Maintenance")
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertNotCalled(t, "Increase", 1)
+	status := man.Status()
+	assert.Equal(t, alice.ActionPaused, status.Action)
+	assert.Equal(t, alice.OverridePause, status.Override.Kind)
+	assert.Equal(t, "Maintenance", status.Override.Reason)
+
+	// The pause expires by itself
+	*now = now.Add(time.Hour)
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionScaled, man.Status().Action)
+	assert.Nil(t, man.Override())
+	inv.AssertExpectations(t)
+}
+
+func TestManager_Resume(t *testing.T) {
+	man, inv, str, _ := setupOverrideTest()
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	down := alice.SCALEDOWN
+	str.On("Evaluate").Return(&down, nil)
+
+	man.Pause(0, "")
+	assert.NotNil(t, man.Override())
+	assert.Nil(t, man.Override().Until)
+	man.Resume()
+	assert.Nil(t, man.Override())
+	inv.On("Decrease", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+}
+
+func TestManager_Pin(t *testing.T) {
+	man, inv, str, _ := setupOverrideTest()
+	man.Config.Set("scale_up", false)
+	inv.On("Status").Return(alice.OK, nil)
+	down := alice.SCALEDOWN
+	str.On("Evaluate").Return(&down, nil)
+
+	assert.Error(t, man.Pin(-1, time.Hour, ""))
+	assert.Error(t, man.Pin(12, 0, ""))
+	assert.NoError(t, man.Pin(12, time.Hour, "Sale"))
+
+	// Pinning ignores the strategy and the scale_up setting
+	inv.On("Total").Return(10, nil).Times(3)
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionPinned, man.Status().Action)
+
+	inv.On("Total").Return(12, nil)
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionPinned, man.Status().Action)
+	inv.AssertNotCalled(t, "Decrease", 1)
+	inv.AssertExpectations(t)
+}
+
+func TestManager_Force(t *testing.T) {
+	man, inv, _, _ := setupOverrideTest()
+	man.Config.Set("scale_down", false)
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+
+	assert.Error(t, man.Force(ctx, 0, ""))
+	inv.On("Decrease", 3).Return(nil).Once()
+	assert.NoError(t, man.Force(ctx, -3, "Too big"))
+	status := man.Status()
+	assert.Equal(t, alice.ActionForced, status.Action)
+	assert.Equal(t, "Forced: Too big", status.Reason)
+
+	elector := MockElector{}
+	elector.On("IsLeader").Return(false)
+	man.Elector = &elector
+	assert.Error(t, man.Force(ctx, 1, ""))
+	inv.AssertNotCalled(t, "Increase", 1)
+	inv.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package alice
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// PagerDutyNotifier triggers a PagerDuty alert for each event, through the Events API (v2). Alerts for the same type of
+// event from the same manager are grouped in to one incident, which is resolved when the inventory or monitor
+// recovers. Unless it's configured with events of its own, it only hears about failures and recoveries.
+type PagerDutyNotifier struct {
+	log    *logrus.Entry
+	config PagerDutyConfig
+	client *http.Client
+}
+
+// PagerDutyConfig is the configuration of a PagerDutyNotifier
+type PagerDutyConfig struct {
+	RoutingKey string        `config:"routing_key" required:"true" description:"Integration key of the PagerDuty service"`
+	URL        string        `config:"url" default:"https://events.pagerduty.com/v2/enqueue" description:"URL of the Events API"`
+	Source     string        `config:"source" default:"alice" description:"Where the alerts say they came from"`
+	Timeout    time.Duration `config:"timeout" default:"10s" description:"Give up on a request after this long"`
+}
+
+// pagerDutySeverities are how serious each type of event is
+var pagerDutySeverities = map[EventType]string{
+	EventScaledUp:        "info",
+	EventScaledDown:      "info",
+	EventAdvisory:        "warning",
+	EventRefused:         "error",
+	EventInventoryFailed: "critical",
+	EventMonitorFailing:  "error",
+	EventApprovalNeeded:  "warning",
+	EventMetricsStale:    "warning",
+}
+
+// pagerDutyResolves are the events that resolve the incidents of other events
+var pagerDutyResolves = map[EventType]EventType{
+	EventInventoryRecovered: EventInventoryFailed,
+	EventMonitorRecovered:   EventMonitorFailing,
+}
+
+// pagerDutyEvent is the payload of the Events API
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"` // Not needed to resolve an incident
+}
+
+type pagerDutyPayload struct {
+	Summary       string `json:"summary"`
+	Source        string `json:"source"`
+	Severity      string `json:"severity"`
+	Timestamp     string `json:"timestamp"`
+	Component     string `json:"component"`
+	Class         string `json:"class"`
+	CustomDetails Event  `json:"custom_details"`
+}
+
+// PagerDuty rejects summaries longer than this
+const pagerDutyMaxSummary = 1024
+
+// NewPagerDutyNotifier creates a new Notifier
+func NewPagerDutyNotifier(config *viper.Viper, log *logrus.Entry) (Notifier, error) {
+	var c PagerDutyConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	return &PagerDutyNotifier{log: log, config: c, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+// DefaultEvents are the events worth paging someone about, and the recoveries that resolve them
+func (p *PagerDutyNotifier) DefaultEvents() []EventType {
+	return []EventType{EventRefused, EventInventoryFailed, EventMonitorFailing, EventInventoryRecovered, EventMonitorRecovered}
+}
+
+// Notify triggers an alert for the event, or resolves the incident of the failure it recovers from
+func (p *PagerDutyNotifier) Notify(ctx context.Context, event Event) error {
+	if failure, ok := pagerDutyResolves[event.Type]; ok {
+		p.log.Debugf("Resolving PagerDuty incident for %s event", failure)
+		return postJSON(ctx, p.client, p.config.URL, nil, pagerDutyEvent{
+			RoutingKey:  p.config.RoutingKey,
+			EventAction: "resolve",
+			DedupKey:    pagerDutyDedupKey(event.Manager, failure),
+		})
+	}
+	p.log.Debugf("Triggering PagerDuty alert for %s event", event.Type)
+	summary := event.Message
+	if len(summary) > pagerDutyMaxSummary {
+		// Cut at the start of a character, so as not to leave half of one at the end
+		cut := pagerDutyMaxSummary
+		for cut > 0 && !utf8.RuneStart(summary[cut]) {
+			cut--
+		}
+		summary = summary[:cut]
+	}
+	return postJSON(ctx, p.client, p.config.URL, nil, pagerDutyEvent{
+		RoutingKey:  p.config.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    pagerDutyDedupKey(event.Manager, event.Type),
+		Payload: &pagerDutyPayload{
+			Summary:       summary,
+			Source:        p.config.Source,
+			Severity:      pagerDutySeverities[event.Type],
+			Timestamp:     event.Time.Format(time.RFC3339),
+			Component:     event.Manager,
+			Class:         string(event.Type),
+			CustomDetails: event,
+		},
+	})
+}
+
+// pagerDutyDedupKey groups alerts for the same type of event from the same manager in to one incident
+func pagerDutyDedupKey(manager string, t EventType) string {
+	return "alice/" + manager + "/" + string(t)
+}
@@ -0,0 +1,89 @@
+package alice_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	alice.RegisterNotifier("pagerduty", alice.NewPagerDutyNotifier)
+}
+
+func TestPagerDutyNotifier_Notify(t *testing.T) {
+	var received struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key"`
+		Payload     struct {
+			Summary       string      `json:"summary"`
+			Source        string      `json:"source"`
+			Severity      string      `json:"severity"`
+			Timestamp     string      `json:"timestamp"`
+			CustomDetails alice.Event `json:"custom_details"`
+		} `json:"payload"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	config := viper.New()
+	config.Set("routing_key", "abc123")
+	config.Set("url", server.URL)
+	notifier, err := alice.NewPagerDutyNotifier(config, log)
+	assert.NoError(t, err)
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := alice.Event{Type: alice.EventInventoryFailed, Manager: "web", Time: now, Message: strings.Repeat("x", 2000)}
+	assert.NoError(t, notifier.Notify(ctx, event))
+	assert.Equal(t, "abc123", received.RoutingKey)
+	assert.Equal(t, "trigger", received.EventAction)
+	assert.Equal(t, "alice/web/inventory_failed", received.DedupKey)
+	assert.Len(t, received.Payload.Summary, 1024)
+	assert.Equal(t, "alice", received.Payload.Source)
+	assert.Equal(t, "critical", received.Payload.Severity)
+	assert.Equal(t, "2017-06-01T12:00:00Z", received.Payload.Timestamp)
+	assert.Equal(t, "web", received.Payload.CustomDetails.Manager)
+
+	// Long summaries aren't cut part way through a character
+	event.Message = strings.Repeat("€", 1000)
+	assert.NoError(t, notifier.Notify(ctx, event))
+	assert.True(t, utf8.ValidString(received.Payload.Summary))
+	assert.Equal(t, strings.Repeat("€", 341), received.Payload.Summary)
+}
+
+func TestPagerDutyNotifier_Resolve(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	// Without events of its own, only failures and recoveries are sent
+	config := viper.New()
+	config.Set("oncall", map[string]interface{}{"name": "pagerduty", "routing_key": "abc123", "url": server.URL})
+	notifications, err := alice.NewNotifications(config, log)
+	assert.NoError(t, err)
+	for _, eventType := range []alice.EventType{alice.EventScaledUp, alice.EventMonitorFailing, alice.EventMonitorRecovered} {
+		assert.NoError(t, notifications.Notify(ctx, alice.Event{Type: eventType, Manager: "web"}))
+	}
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "trigger", received[0]["event_action"])
+		assert.Equal(t, "alice/web/monitor_failing", received[0]["dedup_key"])
+		assert.Equal(t, "resolve", received[1]["event_action"])
+		assert.Equal(t, "alice/web/monitor_failing", received[1]["dedup_key"])
+		assert.Nil(t, received[1]["payload"])
+	}
+}
@@ -0,0 +1,399 @@
+package alice
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Plugins describe the configuration they take with a struct, whose fields are tagged with:
+//
+//	config:"key"            the key in the plugin's block of configuration (fields without it are ignored)
+//	default:"value"         the value used when the key isn't set
+//	required:"true"         the key must be set
+//	description:"text"      what the setting does, for documentation
+//
+// Fields may be strings, ints, float64s, bools, time.Durations, slices of strings, pointers to any of these (left nil
+// when the key isn't set), structs, or maps from names to any of these. For example:
+//
+//	type MyConfig struct {
+//		URL     string        `config:"url" required:"true" description:"Where to find the API"`
+//		Timeout time.Duration `config:"timeout" default:"30s" description:"Give up on a call after this long"`
+//	}
+//
+// A plugin registers its config struct with RegisterConfig, after which its configuration is checked before the plugin
+// is created, and the struct is used for reference documentation and JSON Schema. Its New function can fill in the
+// struct with LoadConfig.
+
+// Kinds of plugin, as used with RegisterConfig
+const (
+	InventoryPlugin = "inventory"
+	MonitorPlugin   = "monitor"
+	StrategyPlugin  = "strategy"
+	ElectorPlugin   = "elector"
+	NotifierPlugin  = "notifier"
+)
+
+// ConfigErrors are all the problems found with a block of configuration
+type ConfigErrors []error
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ConfigField describes a single setting in a plugin's configuration. Keys within maps are written with <name>,
+// eg thresholds.<name>.min
+type ConfigField struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Create a hash for storing the config structs of registered plugins by kind and name
+var pluginConfigs = map[string]map[string]reflect.Type{}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// RegisterConfig records the config struct for the plugin of the given kind and name. spec is the struct, or a pointer
+// to it.
+func RegisterConfig(kind, name string, spec interface{}) {
+	t := reflect.TypeOf(spec)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Config for %s %s must be a struct", kind, name))
+	}
+	if pluginConfigs[kind] == nil {
+		pluginConfigs[kind] = map[string]reflect.Type{}
+	}
+	pluginConfigs[kind][name] = t
+}
+
+// PluginConfig returns a pointer to a new, empty config struct for the plugin of the given kind and name, or nil if it
+// hasn't registered one
+func PluginConfig(kind, name string) interface{} {
+	t, ok := pluginConfigs[kind][name]
+	if !ok {
+		return nil
+	}
+	return reflect.New(t).Interface()
+}
+
+// checkConfig checks config against the config struct registered for the plugin, if there is one
+func checkConfig(kind, name string, config *viper.Viper) error {
+	spec := PluginConfig(kind, name)
+	if spec == nil {
+		return nil
+	}
+	return LoadConfig(config, spec)
+}
+
+// LoadConfig fills in spec, a pointer to a config struct, from config. Defaults are also set in config, so they apply
+// to anything read from it directly. Every problem found is returned, as ConfigErrors.
+func LoadConfig(config *viper.Viper, spec interface{}) error {
+	v := reflect.ValueOf(spec)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Config must be loaded into a pointer to a struct, not %T", spec)
+	}
+	t := v.Elem().Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("config")
+		if def, ok := t.Field(i).Tag.Lookup("default"); ok && key != "" {
+			config.SetDefault(key, def)
+		}
+	}
+	if errs := loadStruct(config.AllSettings(), v.Elem(), ""); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// loadStruct fills in the fields of v from settings, keyed relative to prefix
+func loadStruct(settings map[string]interface{}, v reflect.Value, prefix string) ConfigErrors {
+	var errs ConfigErrors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		value, ok := settings[key]
+		if !ok || value == nil {
+			if field.Tag.Get("required") == "true" {
+				errs = append(errs, configErrorf(prefix+key, "Must be set"))
+				continue
+			}
+			if value, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+		errs = append(errs, loadValue(value, v.Field(i), prefix+key)...)
+	}
+	return errs
+}
+
+// loadValue sets v to value, converted to v's type
+func loadValue(value interface{}, v reflect.Value, key string) ConfigErrors {
+	var err error
+	switch {
+	case v.Type() == durationType:
+		var d time.Duration
+		if d, err = cast.ToDurationE(value); err == nil {
+			v.SetInt(int64(d))
+		}
+	case v.Kind() == reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		errs := loadValue(value, p.Elem(), key)
+		if len(errs) == 0 {
+			v.Set(p)
+		}
+		return errs
+	case v.Kind() == reflect.String:
+		var s string
+		if s, err = cast.ToStringE(value); err == nil {
+			v.SetString(s)
+		}
+	case v.Kind() == reflect.Int:
+		var n int
+		if n, err = cast.ToIntE(value); err == nil {
+			v.SetInt(int64(n))
+		}
+	case v.Kind() == reflect.Float64:
+		var f float64
+		if f, err = cast.ToFloat64E(value); err == nil {
+			v.SetFloat(f)
+		}
+	case v.Kind() == reflect.Bool:
+		var b bool
+		if b, err = cast.ToBoolE(value); err == nil {
+			v.SetBool(b)
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var s []string
+		if s, err = cast.ToStringSliceE(value); err == nil {
+			v.Set(reflect.ValueOf(s))
+		}
+	case v.Kind() == reflect.Struct:
+		var settings map[string]interface{}
+		if settings, err = cast.ToStringMapE(value); err == nil {
+			return loadStruct(settings, v, key+".")
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		var settings map[string]interface{}
+		if settings, err = cast.ToStringMapE(value); err != nil {
+			break
+		}
+		var errs ConfigErrors
+		m := reflect.MakeMap(v.Type())
+		for name, item := range settings {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			errs = append(errs, loadValue(item, elem, key+"."+name)...)
+			m.SetMapIndex(reflect.ValueOf(name), elem)
+		}
+		v.Set(m)
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return errs
+	default:
+		return ConfigErrors{configErrorf(key, "Can't be loaded into a %s", v.Type())}
+	}
+	if err != nil {
+		return ConfigErrors{configErrorf(key, "Must be %s", typeName(v.Type()))}
+	}
+	return nil
+}
+
+// ConfigFields lists the settings in a config struct, in the order they're declared
+func ConfigFields(spec interface{}) []ConfigField {
+	return structFields(indirect(reflect.TypeOf(spec)), "")
+}
+
+func structFields(t reflect.Type, prefix string) []ConfigField {
+	var fields []ConfigField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		fields = append(fields, ConfigField{
+			Key:         prefix + key,
+			Type:        typeName(field.Type),
+			Default:     field.Tag.Get("default"),
+			Required:    field.Tag.Get("required") == "true",
+			Description: field.Tag.Get("description"),
+		})
+		elem := indirect(field.Type)
+		if elem.Kind() == reflect.Map {
+			key += ".<name>"
+			elem = indirect(elem.Elem())
+		}
+		if elem.Kind() == reflect.Struct && elem != durationType {
+			fields = append(fields, structFields(elem, prefix+key+".")...)
+		}
+	}
+	return fields
+}
+
+// typeName describes a type for people writing configuration
+func typeName(t reflect.Type) string {
+	t = indirect(t)
+	switch {
+	case t == durationType:
+		return "a duration"
+	case t.Kind() == reflect.String:
+		return "a string"
+	case t.Kind() == reflect.Int:
+		return "a whole number"
+	case t.Kind() == reflect.Float64:
+		return "a number"
+	case t.Kind() == reflect.Bool:
+		return "true or false"
+	case t.Kind() == reflect.Slice:
+		return "a list"
+	default:
+		return "a map"
+	}
+}
+
+// ConfigSchema returns a JSON Schema for a config struct
+func ConfigSchema(spec interface{}) map[string]interface{} {
+	return typeSchema(reflect.TypeOf(spec))
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	t = indirect(t)
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Int:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		property := typeSchema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			property["default"] = schemaDefault(def, field.Type)
+		}
+		if field.Tag.Get("required") == "true" {
+			required = append(required, key)
+		}
+		properties[key] = property
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schemaDefault converts a default from a struct tag to the JSON type of its field
+func schemaDefault(def string, t reflect.Type) interface{} {
+	t = indirect(t)
+	if t != durationType {
+		switch t.Kind() {
+		case reflect.Int:
+			return cast.ToInt(def)
+		case reflect.Float64:
+			return cast.ToFloat64(def)
+		case reflect.Bool:
+			return cast.ToBool(def)
+		}
+	}
+	return def
+}
+
+// PluginSchema returns a JSON Schema for the configuration of any registered plugin of the given kind, chosen by its
+// 'name'. Plugins without a config struct accept anything.
+func PluginSchema(kind string, names []string) map[string]interface{} {
+	var options []interface{}
+	for _, name := range names {
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		if t, ok := pluginConfigs[kind][name]; ok {
+			schema = typeSchema(t)
+		}
+		schema["properties"].(map[string]interface{})["name"] = map[string]interface{}{"const": name}
+		schema["required"] = append([]string{"name"}, requiredKeys(schema)...)
+		options = append(options, schema)
+	}
+	return map[string]interface{}{"oneOf": options}
+}
+
+func requiredKeys(schema map[string]interface{}) []string {
+	keys, _ := schema["required"].([]string)
+	return keys
+}
+
+// ConfigFileSchema returns a JSON Schema for a whole config file, covering the plugins of every manager, leader
+// election and notifiers. Other settings are allowed but not described.
+func ConfigFileSchema() map[string]interface{} {
+	manager := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"inventory": PluginSchema(InventoryPlugin, Inventories()),
+			"monitor":   PluginSchema(MonitorPlugin, Monitors()),
+			"monitors": map[string]interface{}{
+				"type": "object",
+				"additionalProperties": map[string]interface{}{
+					"allOf": []interface{}{ConfigSchema(MonitorRouterConfig{}), PluginSchema(MonitorPlugin, Monitors())},
+				},
+			},
+			"strategy": PluginSchema(StrategyPlugin, Strategies()),
+		},
+		"required": []string{"inventory", "strategy"},
+	}
+	return map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title":   "Alice configuration",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"managers":        map[string]interface{}{"type": "object", "additionalProperties": manager},
+			"leader_election": PluginSchema(ElectorPlugin, Electors()),
+			"notifiers": map[string]interface{}{
+				"type": "object",
+				"additionalProperties": map[string]interface{}{
+					"allOf": []interface{}{ConfigSchema(NotificationConfig{}), PluginSchema(NotifierPlugin, Notifiers())},
+				},
+			},
+		},
+		"required": []string{"managers"},
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,103 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPluginConfig struct {
+	URL     string                     `config:"url" required:"true" description:"Where to find the API"`
+	Timeout time.Duration              `config:"timeout" default:"30s" description:"Give up on a call after this long"`
+	Retries int                        `config:"retries" default:"3"`
+	Limit   *float64                   `config:"limit"`
+	Tags    []string                   `config:"tags"`
+	Queries map[string]testQueryConfig `config:"queries"`
+	ignored string
+}
+
+type testQueryConfig struct {
+	Query  string `config:"query" required:"true"`
+	Weight int    `config:"weight" default:"1"`
+}
+
+func TestLoadConfig(t *testing.T) {
+	config := viper.New()
+	config.Set("url", "http://example.com")
+	config.Set("tags", []string{"a", "b"})
+	config.Set("queries", map[string]interface{}{"users": map[string]interface{}{"query": "sum:users"}})
+	var c testPluginConfig
+	assert.NoError(t, alice.LoadConfig(config, &c))
+	assert.Equal(t, "http://example.com", c.URL)
+	assert.Equal(t, 30*time.Second, c.Timeout)
+	assert.Equal(t, 3, c.Retries)
+	assert.Nil(t, c.Limit)
+	assert.Equal(t, []string{"a", "b"}, c.Tags)
+	assert.Equal(t, testQueryConfig{Query: "sum:users", Weight: 1}, c.Queries["users"])
+
+	// Defaults also apply to reading the config directly
+	assert.Equal(t, 30*time.Second, config.GetDuration("timeout"))
+
+	config.Set("limit", 0.5)
+	assert.NoError(t, alice.LoadConfig(config, &c))
+	assert.Equal(t, 0.5, *c.Limit)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	config := viper.New()
+	config.Set("timeout", "soon")
+	config.Set("retries", "many")
+	config.Set("queries", map[string]interface{}{"users": map[string]interface{}{"weight": 2}})
+	err := alice.LoadConfig(config, &testPluginConfig{})
+	assert.IsType(t, alice.ConfigErrors{}, err)
+	assert.Equal(t, "url: Must be set; timeout: Must be a duration; retries: Must be a whole number; queries.users.query: Must be set", err.Error())
+
+	assert.Error(t, alice.LoadConfig(config, testPluginConfig{}))
+}
+
+func TestConfigFields(t *testing.T) {
+	fields := alice.ConfigFields(testPluginConfig{})
+	assert.Len(t, fields, 8)
+	assert.Equal(t, alice.ConfigField{Key: "url", Type: "a string", Required: true, Description: "Where to find the API"}, fields[0])
+	assert.Equal(t, alice.ConfigField{Key: "timeout", Type: "a duration", Default: "30s", Description: "Give up on a call after this long"}, fields[1])
+	assert.Equal(t, "a number", fields[3].Type)
+	assert.Equal(t, "queries.<name>.query", fields[6].Key)
+	assert.Equal(t, "queries.<name>.weight", fields[7].Key)
+}
+
+func TestConfigSchema(t *testing.T) {
+	schema := alice.ConfigSchema(&testPluginConfig{})
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []string{"url"}, schema["required"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Len(t, properties, 6)
+	assert.Equal(t, 3, properties["retries"].(map[string]interface{})["default"])
+	assert.Equal(t, "Where to find the API", properties["url"].(map[string]interface{})["description"])
+	queries := properties["queries"].(map[string]interface{})["additionalProperties"].(map[string]interface{})
+	assert.Equal(t, []string{"query"}, queries["required"])
+}
+
+func TestRegisterConfig(t *testing.T) {
+	alice.RegisterInventory("configured", NewMockInventory)
+	alice.RegisterConfig(alice.InventoryPlugin, "configured", testPluginConfig{})
+	assert.IsType(t, &testPluginConfig{}, alice.PluginConfig(alice.InventoryPlugin, "configured"))
+	assert.Nil(t, alice.PluginConfig(alice.InventoryPlugin, "mock"))
+
+	config := viper.New()
+	config.Set("name", "configured")
+	_, err := alice.NewInventory(config, log)
+	assert.EqualError(t, err, "url: Must be set")
+	config.Set("url", "http://example.com")
+	inv, err := alice.NewInventory(config, log)
+	assert.NoError(t, err)
+	assert.IsType(t, &MockInventory{}, inv)
+
+	schema := alice.PluginSchema(alice.InventoryPlugin, []string{"configured", "mock"})
+	options := schema["oneOf"].([]interface{})
+	assert.Len(t, options, 2)
+	assert.Equal(t, []string{"name", "url"}, options[0].(map[string]interface{})["required"])
+	assert.Panics(t, func() { alice.RegisterConfig(alice.InventoryPlugin, "broken", "not a struct") })
+}
@@ -0,0 +1,97 @@
+package alice
+
+import (
+	"fmt"
+	"time"
+)
+
+// scaling is a scaling action made by a Manager, remembered so that later ones can be rate limited
+type scaling struct {
+	time        time.Time
+	direction   string
+	step        int
+	totalBefore int
+}
+
+// rateLimit checks a change of step in the given direction against the limits configured under rate_limit, returning
+// the step that may be taken. The number of scale ups or downs within period is limited by scale_ups and scale_downs,
+// and if either has been reached the step is 0. The total size of the changes made within change_period is limited
+// to max_change_percent of the inventory total before the first of them (or total, if there were none), so the step
+// may be clipped. At least one resource can always be changed, so that small inventories can still scale. When the
+// step is 0, the reason is returned as well.
+func (m *Manager) rateLimit(direction string, step int, total int) (int, string) {
+	if !m.Config.IsSet("rate_limit") {
+		return step, ""
+	}
+	period, changePeriod := m.ratePeriod("rate_limit.period"), m.ratePeriod("rate_limit.change_period")
+	now := m.now()
+	m.forget(now)
+
+	key := "rate_limit.scale_" + direction + "s"
+	if m.Config.IsSet(key) {
+		since := now.Add(-period)
+		count := 0
+		for _, c := range m.history {
+			if c.direction == direction && c.time.After(since) {
+				count++
+			}
+		}
+		if limit := m.Config.GetInt(key); count >= limit {
+			m.Logger.Warnf("Not scaling %s as there have already been %d scale %ss in the last %v", direction, count, direction, period)
+			return 0, fmt.Sprintf("Reached the limit of %d scale %ss per %v", limit, direction, period)
+		}
+	}
+
+	if m.Config.IsSet("rate_limit.max_change_percent") {
+		since := now.Add(-changePeriod)
+		baseline, changed := total, 0
+		for i := len(m.history) - 1; i >= 0 && m.history[i].time.After(since); i-- {
+			baseline = m.history[i].totalBefore
+			changed += m.history[i].step
+		}
+		percent := m.Config.GetInt("rate_limit.max_change_percent")
+		budget := baseline * percent / 100
+		if budget < 1 {
+			budget = 1
+		}
+		if changed >= budget {
+			m.Logger.Warnf("Not scaling %s as %d resources have already changed in the last %v, the limit is %d%% of %d", direction, changed, changePeriod, percent, baseline)
+			return 0, fmt.Sprintf("Reached the limit of changing %d%% of the inventory per %v", percent, changePeriod)
+		}
+		if step > budget-changed {
+			m.Logger.Warnf("Clipping scale %s by %d to %d to change no more than %d%% of the inventory in %v", direction, step, budget-changed, percent, changePeriod)
+			step = budget - changed
+		}
+	}
+	return step, ""
+}
+
+// remember records a scaling action for rate limiting, forgetting old ones so that the history doesn't grow without
+// end when no rate limit is checked
+func (m *Manager) remember(direction string, step int, totalBefore int) {
+	now := m.now()
+	m.forget(now)
+	m.history = append(m.history, scaling{time: now, direction: direction, step: step, totalBefore: totalBefore})
+}
+
+// forget drops scaling actions too old to count towards any rate limit
+func (m *Manager) forget(now time.Time) {
+	longest := m.ratePeriod("rate_limit.period")
+	if period := m.ratePeriod("rate_limit.change_period"); period > longest {
+		longest = period
+	}
+	since := now.Add(-longest)
+	i := 0
+	for i < len(m.history) && !m.history[i].time.After(since) {
+		i++
+	}
+	m.history = m.history[i:]
+}
+
+// ratePeriod returns the period of time a rate limit applies over, an hour unless configured
+func (m *Manager) ratePeriod(key string) time.Duration {
+	if !m.Config.IsSet(key) {
+		return time.Hour
+	}
+	return m.Config.GetDuration(key)
+}
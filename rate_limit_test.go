@@ -0,0 +1,65 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRateLimitTest(limits map[string]interface{}) (*alice.Manager, *MockInventory, *MockStrategy, *time.Time) {
+	man, inv, str, now := setupOverrideTest()
+	man.Config.Set("rate_limit", limits)
+	inv.On("Status").Return(alice.OK, nil)
+	return man, inv, str, now
+}
+
+func TestManager_RunRateLimitCount(t *testing.T) {
+	man, inv, str, now := setupRateLimitTest(map[string]interface{}{"scale_ups": 2, "scale_downs": 1, "period": "1h"})
+	inv.On("Total").Return(10, nil)
+	up, down := alice.SCALEUP, alice.SCALEDOWN
+	str.On("Evaluate").Return(&up, nil).Times(3)
+	inv.On("Increase", 1).Return(nil).Twice()
+	for i := 0; i < 3; i++ {
+		*now = now.Add(10 * time.Minute)
+		assert.NoError(t, man.Run(ctx))
+	}
+	assert.Equal(t, alice.ActionRateLimited, man.Status().Action)
+
+	// Scale downs have their own limit
+	str.On("Evaluate").Return(&down, nil).Twice()
+	inv.On("Decrease", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionRateLimited, man.Status().Action)
+
+	// Once the first scale up is an hour old another is allowed
+	*now = now.Add(41 * time.Minute)
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 1).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionScaled, man.Status().Action)
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunRateLimitChange(t *testing.T) {
+	man, inv, str, now := setupRateLimitTest(map[string]interface{}{"max_change_percent": 50, "change_period": "30m"})
+	down := alice.Recommendation(-3)
+
+	// 50% of 10 is 5, so after changing by 3 only 2 more can change
+	inv.On("Total").Return(10, nil).Times(3)
+	str.On("Evaluate").Return(&down, nil)
+	inv.On("Decrease", 3).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.On("Total").Return(7, nil)
+	inv.On("Decrease", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, alice.ActionRateLimited, man.Status().Action)
+
+	*now = now.Add(30 * time.Minute)
+	inv.On("Decrease", 3).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+}
@@ -1,8 +1,11 @@
 package alice
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"math"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -14,6 +17,19 @@ type RatioStrategy struct {
 	Inventory Inventory
 	Monitor   Monitor
 	log       *logrus.Entry
+	lastEvaluation
+}
+
+// RatioConfig is the configuration of a RatioStrategy
+type RatioConfig struct {
+	Mode   string                       `config:"mode" default:"direction" description:"'direction' to scale a step at a time, or 'target' to move straight to the total the ratios ask for"`
+	Ratios map[string]MetricRatioConfig `config:"ratios" required:"true" description:"The ratio to keep for each metric, by metric name"`
+}
+
+// MetricRatioConfig is the ratio a RatioStrategy keeps between a metric and the inventory
+type MetricRatioConfig struct {
+	Metric    int `config:"metric" required:"true" description:"For every this much of the metric..."`
+	Inventory int `config:"inventory" required:"true" description:"...keep this many resources"`
 }
 
 // NewRatioStrategy creates a new Strategy
@@ -21,52 +37,139 @@ func NewRatioStrategy(config *viper.Viper, inv Inventory, mon Monitor, log *logr
 	return &RatioStrategy{Config: config, Inventory: inv, Monitor: mon, log: log}, nil
 }
 
-// Evaluate will pull data from the associated Monitor and return a scaling recommendation
-func (r *RatioStrategy) Evaluate() (*Recommendation, error) {
-	finalRecommendation := SCALEDOWN
+// Metrics returns the names of the metrics the strategy has ratios for, sorted
+func (r *RatioStrategy) Metrics() []string {
+	var names []string
+	for metricName := range r.Config.GetStringMap("ratios") {
+		names = append(names, metricName)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	var metricNames []string
+// Validate checks every ratio has positive 'metric' and 'inventory' numbers
+func (r *RatioStrategy) Validate() []error {
+	if !r.Config.IsSet("ratios") {
+		return []error{configErrorf("ratios", "Must provide at least one ratio")}
+	}
+	var errs []error
 	for metricName := range r.Config.GetStringMap("ratios") {
-		metricNames = append(metricNames, metricName)
+		for _, k := range []string{"metric", "inventory"} {
+			key := "ratios." + metricName + "." + k
+			if !r.Config.IsSet(key) {
+				errs = append(errs, configErrorf(key, "Strategy requires 'metric' and 'inventory' numbers for each ratio"))
+			} else if r.Config.GetInt(key) <= 0 {
+				errs = append(errs, configErrorf(key, "Must be more than 0"))
+			}
+		}
 	}
-	metricUpdates, err := r.Monitor.GetUpdatedMetrics(metricNames)
+	return errs
+}
+
+// Evaluate will pull data from the associated Monitor and return a scaling recommendation, explaining it for Explain
+func (r *RatioStrategy) Evaluate(ctx context.Context) (*Recommendation, error) {
+	finalRecommendation := SCALEDOWN
+	r.remember(nil)
+
+	targets, err := r.targets(ctx)
 	if err != nil {
 		return nil, err
 	}
-	currentTotal, err := r.Inventory.Total()
+	currentTotal, err := r.Inventory.Total(ctx)
 	if err != nil {
 		return nil, err
 	}
-	for _, metric := range *metricUpdates {
-		metricConfig := r.Config.Sub("ratios." + metric.Name)
+	evaluation := &Evaluation{}
+	for _, target := range targets {
 		var metricRecommendation Recommendation
-		if !metricConfig.IsSet("metric") || !metricConfig.IsSet("inventory") {
-			return nil, errors.New("Strategy requires 'metric' and 'inventory' numbers for each ratio")
-		}
-
-		m := float64(metricConfig.GetInt("metric"))
-		i := float64(metricConfig.GetInt("inventory"))
-		c := float64(metric.CurrentReading)
-		// Desired state is m/i = c/t. Therefore we should scale t until t = ci/m.
-		// Eg if config says metric to inventory should be 3/2, and the current reading is 9, then total
-		// inventory should be 9*2/3 = 6. Always round UP to nearest integer.
-		t := int(math.Ceil(c * i / m))
-
 		switch {
-		case currentTotal < t:
+		case currentTotal < target.total:
 			metricRecommendation = SCALEUP
-		case currentTotal == t:
+		case currentTotal == target.total:
 			metricRecommendation = HOLD
-		case currentTotal > t:
+		case currentTotal > target.total:
 			metricRecommendation = SCALEDOWN
 		default:
 			return nil, errors.New("Strategy: Something went wrong")
 		}
-		r.log.Debugf("Metric: %v value: %v desired metric/inventory ratio: %v/%v. Suggests %v.", metric.Name, metric.CurrentReading, m, i, metricRecommendation)
+		r.log.Debugf("Metric: %v value: %v desired metric/inventory ratio: %v/%v. Suggests %v.", target.name, target.reading, target.metric, target.inventory, metricRecommendation)
+		e := target.evaluation()
+		e.Recommendation = metricRecommendation
+		e.Reason += fmt.Sprintf(", and there are %d", currentTotal)
+		evaluation.Metrics = append(evaluation.Metrics, e)
 		if finalRecommendation < metricRecommendation { // Worst case scenario wins
 			finalRecommendation = metricRecommendation
 		}
 	}
 	r.log.Debugf("Recommending %v as safest option", finalRecommendation)
+	evaluation.Recommendation = finalRecommendation
+	r.remember(evaluation)
 	return &finalRecommendation, nil
 }
+
+// Target returns the inventory total needed to satisfy every ratio. The largest total wins as the safest option.
+func (r *RatioStrategy) Target(ctx context.Context) (int, error) {
+	r.remember(nil)
+	targets, err := r.targets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	evaluation := &Evaluation{Target: &total}
+	for _, target := range targets {
+		r.log.Debugf("Metric: %v value: %v desired metric/inventory ratio: %v/%v. Suggests a total of %v.", target.name, target.reading, target.metric, target.inventory, target.total)
+		evaluation.Metrics = append(evaluation.Metrics, target.evaluation())
+		if target.total > total {
+			total = target.total
+		}
+	}
+	r.log.Debugf("Recommending a total of %v as safest option", total)
+	r.remember(evaluation)
+	return total, nil
+}
+
+// ratioTarget is the inventory total a single ratio asks for given the current metric reading
+type ratioTarget struct {
+	name      string
+	reading   float64
+	metric    float64
+	inventory float64
+	total     int
+}
+
+// evaluation explains the target, without a recommendation
+func (t ratioTarget) evaluation() MetricEvaluation {
+	total := t.total
+	ratio := formatReading(t.metric) + ":" + formatReading(t.inventory)
+	return MetricEvaluation{
+		Name:    t.name,
+		Reading: t.reading,
+		Ratio:   ratio,
+		Target:  &total,
+		Reason:  fmt.Sprintf("%s is %s, which needs %d for a ratio of %s", t.name, formatReading(t.reading), t.total, ratio),
+	}
+}
+
+func (r *RatioStrategy) targets(ctx context.Context) ([]ratioTarget, error) {
+	metricUpdates, err := r.Monitor.GetUpdatedMetrics(ctx, r.Metrics())
+	if err != nil {
+		return nil, err
+	}
+	var targets []ratioTarget
+	for _, metric := range *metricUpdates {
+		metricConfig := r.Config.Sub("ratios." + metric.Name)
+		if metricConfig == nil || !metricConfig.IsSet("metric") || !metricConfig.IsSet("inventory") {
+			return nil, errors.New("Strategy requires 'metric' and 'inventory' numbers for each ratio")
+		}
+
+		m := float64(metricConfig.GetInt("metric"))
+		i := float64(metricConfig.GetInt("inventory"))
+		c := float64(metric.CurrentReading)
+		// Desired state is m/i = c/t. Therefore we should scale t until t = ci/m.
+		// Eg if config says metric to inventory should be 3/2, and the current reading is 9, then total
+		// inventory should be 9*2/3 = 6. Always round UP to nearest integer.
+		t := int(math.Ceil(c * i / m))
+		targets = append(targets, ratioTarget{name: metric.Name, reading: metric.CurrentReading, metric: m, inventory: i, total: t})
+	}
+	return targets, nil
+}
@@ -35,48 +35,112 @@ func TestRatioStrategy_Evaluate(t *testing.T) {
 	setupRatioStrategyTest()
 
 	mockInventory.On("Total").Return(1, errors.New("foo")).Once()
-	recommendation, error := ratioStrategy.Evaluate()
+	recommendation, error := ratioStrategy.Evaluate(ctx)
 	assert.Error(t, error)
 
 	config.Set("ratios.active_users.metric", 100)
 	config.Set("ratios.active_users.inventory", 1)
 	metricUpdates = append(metricUpdates, alice.MetricUpdate{Name: "active_users", CurrentReading: 100})
 	mockInventory.On("Total").Return(1, nil).Once()
-	recommendation, error = ratioStrategy.Evaluate()
+	recommendation, error = ratioStrategy.Evaluate(ctx)
 	assert.Nil(t, error)
 	assert.Equal(t, *recommendation, alice.HOLD)
 	mockInventory.On("Total").Return(2, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 	mockInventory.On("Total").Return(0, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 	config.Set("ratios.active_users.metric", 110)
 	config.Set("ratios.active_users.inventory", 100)
 	metricUpdates[0] = alice.MetricUpdate{Name: "active_users", CurrentReading: 220}
 	mockInventory.On("Total").Return(200, nil).Once()
-	recommendation, error = ratioStrategy.Evaluate()
+	recommendation, error = ratioStrategy.Evaluate(ctx)
 	assert.Nil(t, error)
 	assert.Equal(t, *recommendation, alice.HOLD)
 	mockInventory.On("Total").Return(201, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 	mockInventory.On("Total").Return(199, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 	config.Set("ratios.connections.metric", 120)
 	config.Set("ratios.connections.inventory", 100)
 	metricUpdates = append(metricUpdates, alice.MetricUpdate{Name: "connections", CurrentReading: 220})
 	mockInventory.On("Total").Return(200, nil).Once()
-	recommendation, error = ratioStrategy.Evaluate()
+	recommendation, error = ratioStrategy.Evaluate(ctx)
 	assert.Nil(t, error)
 	assert.Equal(t, *recommendation, alice.HOLD)
 	mockInventory.On("Total").Return(201, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 	mockInventory.On("Total").Return(199, nil).Once()
-	recommendation, _ = ratioStrategy.Evaluate()
+	recommendation, _ = ratioStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 }
+
+func TestRatioStrategy_Target(t *testing.T) {
+	setupRatioStrategyTest()
+
+	config.Set("ratios.active_users.metric", 100)
+	config.Set("ratios.active_users.inventory", 1)
+	config.Set("ratios.connections.metric", 10)
+	config.Set("ratios.connections.inventory", 1)
+	metricUpdates = append(metricUpdates,
+		alice.MetricUpdate{Name: "active_users", CurrentReading: 950},
+		alice.MetricUpdate{Name: "connections", CurrentReading: 40},
+	)
+	target, err := ratioStrategy.Target(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, target)
+
+	metricUpdates[1] = alice.MetricUpdate{Name: "connections", CurrentReading: 120}
+	target, _ = ratioStrategy.Target(ctx)
+	assert.Equal(t, 12, target)
+	mockInventory.AssertNotCalled(t, "Total")
+}
+
+func TestRatioStrategy_Metrics(t *testing.T) {
+	setupRatioStrategyTest()
+	config.Set("ratios.users.metric", 100)
+	config.Set("ratios.connections.metric", 10)
+	assert.Equal(t, []string{"connections", "users"}, ratioStrategy.Metrics())
+}
+
+func TestRatioStrategy_Validate(t *testing.T) {
+	setupRatioStrategyTest()
+	assert.Len(t, ratioStrategy.Validate(), 1)
+	config.Set("ratios.users.metric", 100)
+	config.Set("ratios.users.inventory", 1)
+	assert.Empty(t, ratioStrategy.Validate())
+	config.Set("ratios.queue.metric", 0)
+	assert.Len(t, ratioStrategy.Validate(), 2)
+}
+
+func TestRatioStrategy_Explain(t *testing.T) {
+	setupRatioStrategyTest()
+	config.Set("ratios.active_users.metric", 100)
+	config.Set("ratios.active_users.inventory", 1)
+	config.Set("ratios.connections.metric", 10)
+	config.Set("ratios.connections.inventory", 1)
+	metricUpdates = append(metricUpdates,
+		alice.MetricUpdate{Name: "active_users", CurrentReading: 950},
+		alice.MetricUpdate{Name: "connections", CurrentReading: 120},
+	)
+	mockInventory.On("Total").Return(11, nil).Once()
+	ratioStrategy.Evaluate(ctx)
+	evaluation := ratioStrategy.Explain()
+	assert.Equal(t, alice.SCALEUP, evaluation.Recommendation)
+	assert.Equal(t, "connections", evaluation.Deciding)
+	assert.Equal(t, "connections is 120, which needs 12 for a ratio of 10:1, and there are 11", evaluation.String())
+	assert.Equal(t, "100:1", evaluation.Metrics[0].Ratio)
+	assert.Equal(t, 10, *evaluation.Metrics[0].Target)
+	assert.Equal(t, alice.SCALEDOWN, evaluation.Metrics[0].Recommendation)
+
+	ratioStrategy.Target(ctx)
+	evaluation = ratioStrategy.Explain()
+	assert.Equal(t, 12, *evaluation.Target)
+	assert.Equal(t, "connections is 120, which needs 12 for a ratio of 10:1", evaluation.String())
+}
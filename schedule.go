@@ -0,0 +1,144 @@
+package alice
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"github.com/spf13/viper"
+)
+
+// window is a period of time, repeating on a cron schedule, during which a Manager keeps its inventory within
+// different limits, or at a fixed total.
+type window struct {
+	name     string
+	schedule cron.Schedule
+	duration time.Duration
+	location *time.Location
+	minTotal *int
+	maxTotal *int
+	total    *int
+}
+
+// newWindows reads the schedule windows under the 'schedules' key of a manager's config, sorted by name. Each window
+// starts according to a standard five field cron expression and lasts for its duration.
+func newWindows(config *viper.Viper) ([]window, error) {
+	names := make([]string, 0)
+	for name := range config.GetStringMap("schedules") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	windows := make([]window, 0, len(names))
+	for _, name := range names {
+		windowConfig := config.Sub("schedules." + name)
+		if windowConfig == nil {
+			return nil, errors.Errorf("Schedule %s has no configuration", name)
+		}
+		w, err := newWindow(name, windowConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid schedule %s", name)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func newWindow(name string, config *viper.Viper) (window, error) {
+	w := window{name: name, location: time.Local}
+	for _, k := range []string{"cron", "duration"} {
+		if !config.IsSet(k) {
+			return w, errors.Errorf("Missing config: %s", k)
+		}
+	}
+	var err error
+	if w.schedule, err = cron.ParseStandard(config.GetString("cron")); err != nil {
+		return w, err
+	}
+	if w.duration = config.GetDuration("duration"); w.duration <= 0 {
+		return w, errors.Errorf("Invalid duration %v", config.Get("duration"))
+	}
+	if config.IsSet("timezone") {
+		if w.location, err = time.LoadLocation(config.GetString("timezone")); err != nil {
+			return w, err
+		}
+	}
+	for key, limit := range map[string]**int{"min_total": &w.minTotal, "max_total": &w.maxTotal, "total": &w.total} {
+		if config.IsSet(key) {
+			value := config.GetInt(key)
+			if value < 0 {
+				return w, errors.Errorf("%s can't be negative", key)
+			}
+			*limit = &value
+		}
+	}
+	if w.minTotal == nil && w.maxTotal == nil && w.total == nil {
+		return w, errors.New("Must set at least one of min_total, max_total or total")
+	}
+	return w, nil
+}
+
+// active returns true if the window has started within its duration of now. Start times are worked out in the
+// window's timezone.
+func (w window) active(now time.Time) bool {
+	now = now.In(w.location)
+	return !w.schedule.Next(now.Add(-w.duration)).After(now)
+}
+
+// activeWindows returns the manager's schedule windows that are active now
+func (m *Manager) activeWindows() []window {
+	var active []window
+	now := m.now()
+	for _, w := range m.windows {
+		if w.active(now) {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// schedule adjusts a recommendation so that the inventory ends up within the limits of every active schedule window.
+// If any window sets a total, the largest of those is used instead of the recommendation. Otherwise the highest
+// min_total and lowest max_total apply, with min_total winning if they overlap. The inventory total is only fetched
+// if a window is active and total is nil.
+func (m *Manager) schedule(ctx context.Context, rec Recommendation, total *int) (Recommendation, error) {
+	active := m.activeWindows()
+	if len(active) == 0 {
+		return rec, nil
+	}
+	if total == nil {
+		current, err := m.Inventory.Total(ctx)
+		if err != nil {
+			return rec, err
+		}
+		total = &current
+	}
+	var min, max, fixed *int
+	for _, w := range active {
+		m.Logger.Infof("Schedule %s is active", w.name)
+		if w.total != nil && (fixed == nil || *w.total > *fixed) {
+			fixed = w.total
+		}
+		if w.minTotal != nil && (min == nil || *w.minTotal > *min) {
+			min = w.minTotal
+		}
+		if w.maxTotal != nil && (max == nil || *w.maxTotal < *max) {
+			max = w.maxTotal
+		}
+	}
+	target := *total + int(rec)
+	if fixed != nil {
+		m.Logger.Infof("Scheduled total is %d, current total is %d", *fixed, *total)
+		return Recommendation(*fixed - *total), nil
+	}
+	if max != nil && target > *max {
+		m.Logger.Infof("Lowering total of %d to scheduled max_total of %d", target, *max)
+		target = *max
+	}
+	if min != nil && target < *min {
+		m.Logger.Infof("Raising total of %d to scheduled min_total of %d", target, *min)
+		target = *min
+	}
+	return Recommendation(target - *total), nil
+}
@@ -0,0 +1,94 @@
+package alice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupScheduleTest(t *testing.T, schedules map[string]interface{}, now *time.Time) (*alice.Manager, *MockInventory, *MockStrategy) {
+	config := viper.New()
+	config.Set("inventory.name", "mock")
+	config.Set("monitor.name", "mock")
+	config.Set("strategy.name", "mock")
+	config.Set("schedules", schedules)
+	man, err := alice.New("web", config, log)
+	assert.NoError(t, err)
+	inv := MockInventory{}
+	str := MockStrategy{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	man.Inventory, man.Strategy = &inv, &str
+	man.Clock = func() time.Time { return *now }
+	return man, &inv, &str
+}
+
+func TestManager_RunSchedules(t *testing.T) {
+	// 17:00 in New York
+	now := time.Date(2017, 1, 10, 22, 0, 0, 0, time.UTC)
+	man, inv, str := setupScheduleTest(t, map[string]interface{}{
+		"evenings": map[string]interface{}{
+			"cron": "0 18 * * *", "duration": "4h", "timezone": "America/New_York", "min_total": 12, "max_total": 14,
+		},
+		"black_friday": map[string]interface{}{"cron": "0 0 24 11 *", "duration": "96h", "total": 20},
+	}, &now)
+	hold, up := alice.HOLD, alice.Recommendation(8)
+	str.On("Evaluate").Return(&hold, nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Empty(t, man.Status().Schedules)
+
+	// The evening window raises the minimum, then caps the maximum
+	now = time.Date(2017, 1, 10, 23, 0, 0, 0, time.UTC)
+	str.On("Evaluate").Return(&hold, nil).Once()
+	inv.On("Increase", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, []string{"evenings"}, man.Status().Schedules)
+	str.On("Evaluate").Return(&up, nil).Once()
+	inv.On("Increase", 4).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+
+	// A fixed total wins over both the strategy and other windows
+	now = time.Date(2017, 11, 26, 23, 30, 0, 0, time.UTC)
+	str.On("Evaluate").Return(&hold, nil).Once()
+	inv.On("Increase", 10).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, []string{"black_friday", "evenings"}, man.Status().Schedules)
+	inv.AssertExpectations(t)
+}
+
+func TestManager_RunSchedulesTargetMode(t *testing.T) {
+	now := time.Date(2017, 1, 10, 12, 0, 0, 0, time.UTC)
+	man, inv, _ := setupScheduleTest(t, map[string]interface{}{
+		"daytime": map[string]interface{}{"cron": "0 9 * * *", "duration": "8h", "timezone": "UTC", "max_total": 8},
+	}, &now)
+	str := MockTargetStrategy{}
+	man.Strategy = &str
+	man.Config.Set("strategy.mode", "target")
+	str.On("Target").Return(15, nil).Once()
+	inv.On("Decrease", 2).Return(nil).Once()
+	assert.NoError(t, man.Run(ctx))
+	inv.AssertExpectations(t)
+}
+
+func TestNewSchedulesInvalid(t *testing.T) {
+	for _, schedule := range []map[string]interface{}{
+		{"duration": "1h", "total": 1},
+		{"cron": "0 9 * * *", "total": 1},
+		{"cron": "every day", "duration": "1h", "total": 1},
+		{"cron": "0 9 * * *", "duration": "-1h", "total": 1},
+		{"cron": "0 9 * * *", "duration": "1h", "timezone": "Nowhere/Special", "total": 1},
+		{"cron": "0 9 * * *", "duration": "1h", "min_total": -1},
+		{"cron": "0 9 * * *", "duration": "1h"},
+	} {
+		config := viper.New()
+		config.Set("inventory.name", "mock")
+		config.Set("monitor.name", "mock")
+		config.Set("strategy.name", "mock")
+		config.Set("schedules", map[string]interface{}{"broken": schedule})
+		_, err := alice.New("web", config, log)
+		assert.Error(t, err, "%v should be invalid", schedule)
+	}
+}
@@ -0,0 +1,185 @@
+package alice
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server is an HTTP API exposing the status of running managers as JSON, and their Prometheus metrics. Managers can
+// also be controlled, with the options for each control given as a JSON body. Controls change the inventory, so they
+// need an 'Authorization: Bearer <token>' header with the server's token, and are refused if it has none.
+//
+//	GET  /status                  the status of every manager
+//	GET  /status/<name>           the status of a single manager
+//	POST /managers/<name>/pause   stop acting on recommendations {"duration": "1h", "reason": "..."}
+//	POST /managers/<name>/resume  cancel a pause or pin
+//	POST /managers/<name>/pin     keep the inventory at a total {"total": 5, "duration": "1h", "reason": "..."}
+//	POST /managers/<name>/force   scale straight away {"amount": -2, "reason": "..."}
+//	POST /managers/<name>/approve carry out the action waiting for approval {"id": 3, "reason": "..."}
+//	POST /managers/<name>/reject  forget the action waiting for approval {"reason": "..."}
+//	GET  /metrics                 Prometheus metrics
+type Server struct {
+	managers func() []*Manager
+	interval func(*Manager) time.Duration
+	token    string
+	log      *logrus.Entry
+	mux      *http.ServeMux
+}
+
+// NewServer creates a new Server. Managers are looked up on every request, so the set of managers can change while
+// the server is running, and interval gives how often each one runs. Without a token managers can't be controlled.
+func NewServer(managers func() []*Manager, interval func(*Manager) time.Duration, token string, log *logrus.Entry) *Server {
+	s := &Server{managers: managers, interval: interval, token: token, log: log, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/status", s.handleStatus)
+	s.mux.HandleFunc("/status/", s.handleStatus)
+	s.mux.HandleFunc("/managers/", s.handleControl)
+	s.mux.Handle("/metrics", promhttp.Handler())
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/status"), "/")
+	if name == "" {
+		statuses := []ManagerStatus{}
+		for _, m := range s.managers() {
+			statuses = append(statuses, m.Status())
+		}
+		s.writeJSON(w, http.StatusOK, statuses)
+		return
+	}
+	m := s.manager(name)
+	if m == nil {
+		s.writeError(w, http.StatusNotFound, "No manager called "+name)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, m.Status())
+}
+
+// control is the body of a request to control a manager
+type control struct {
+	ID       int    `json:"id"`
+	Duration string `json:"duration"`
+	Total    int    `json:"total"`
+	Amount   int    `json:"amount"`
+	Reason   string `json:"reason"`
+}
+
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.token == "" {
+		s.writeError(w, http.StatusForbidden, "Controls are disabled until http.token is set")
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		s.log.Warnf("Refused unauthorised request to %s from %s", r.URL.Path, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		s.writeError(w, http.StatusUnauthorized, "Missing or invalid token")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/managers"), "/"), "/")
+	if len(parts) != 2 {
+		s.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	m := s.manager(parts[0])
+	if m == nil {
+		s.writeError(w, http.StatusNotFound, "No manager called "+parts[0])
+		return
+	}
+	var c control
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	var duration time.Duration
+	if c.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(c.Duration); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid duration: "+err.Error())
+			return
+		}
+	}
+	s.log.Infof("Received request to %s %s", parts[1], m.Name)
+	// A client giving up on the request mustn't abandon a scaling action part way through, so actions get their own
+	// context, which allows them as long as the manager has between runs
+	actionCtx, cancel := context.WithTimeout(context.Background(), s.interval(m))
+	defer cancel()
+	switch parts[1] {
+	case "pause":
+		m.Pause(duration, c.Reason)
+	case "resume":
+		m.Resume()
+	case "pin":
+		if err := m.Pin(c.Total, duration, c.Reason); err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	case "force":
+		if c.Amount == 0 {
+			s.writeError(w, http.StatusBadRequest, "Must force a non-zero amount")
+			return
+		}
+		if err := m.Force(actionCtx, c.Amount, c.Reason); err != nil {
+			s.writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+	case "approve":
+		if err := m.Approve(actionCtx, c.ID, c.Reason); err != nil {
+			s.writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+	case "reject":
+		if err := m.Reject(c.Reason); err != nil {
+			s.writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+	default:
+		s.writeError(w, http.StatusNotFound, "Unknown control "+parts[1])
+		return
+	}
+	s.writeJSON(w, http.StatusOK, m.Status())
+}
+
+// manager finds a running manager by name, or returns nil
+func (s *Server) manager(name string) *Manager {
+	for _, m := range s.managers() {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Errorf("Error writing response: %s", err.Error())
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, code int, message string) {
+	s.writeJSON(w, code, map[string]string{"error": message})
+}
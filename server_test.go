@@ -0,0 +1,131 @@
+package alice_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupServerTest() *alice.Server {
+	config := viper.New()
+	config.Set("inventory.name", "mock")
+	inv := MockInventory{}
+	inv.On("Total").Return(3, nil)
+	inv.On("Status").Return(alice.UPDATING, nil)
+	str := MockStrategy{}
+	rec := alice.SCALEUP
+	str.On("Evaluate").Return(&rec, nil)
+	inv.On("Increase", 1).Return(nil)
+	managers := []*alice.Manager{
+		{Name: "web", Strategy: &str, Inventory: &inv, Logger: log, Config: config},
+		{Name: "workers", Strategy: &str, Inventory: &inv, Logger: log, Config: config},
+	}
+	managers[0].Run(ctx)
+	return alice.NewServer(func() []*alice.Manager { return managers }, func(*alice.Manager) time.Duration { return time.Minute }, "secret", log)
+}
+
+func TestServer_Status(t *testing.T) {
+	server := setupServerTest()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var statuses []alice.ManagerStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "web", statuses[0].Name)
+	assert.Equal(t, alice.SCALEUP, *statuses[0].Recommendation)
+	assert.Equal(t, 3, *statuses[0].InventoryTotal)
+	assert.Equal(t, "UPDATING", statuses[0].InventoryStatus)
+	assert.Nil(t, statuses[1].Recommendation)
+}
+
+func TestServer_StatusByName(t *testing.T) {
+	server := setupServerTest()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/status/web", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status alice.ManagerStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "mock", status.Inventory)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/status/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("POST", "/status/web", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServer_Control(t *testing.T) {
+	server := setupServerTest()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer secret")
+		server.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("/managers/web/pause", `{"duration": "30m", "reason": "Maintenance"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status alice.ManagerStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, alice.OverridePause, status.Override.Kind)
+	assert.NotNil(t, status.Override.Until)
+
+	w = post("/managers/web/resume", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	status = alice.ManagerStatus{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Nil(t, status.Override)
+
+	assert.Equal(t, http.StatusOK, post("/managers/web/pin", `{"total": 4, "duration": "1h"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/managers/web/pin", `{"total": 4}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/managers/web/pause", `{"duration": "soon"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/managers/web/force", `{}`).Code)
+	assert.Equal(t, http.StatusOK, post("/managers/web/force", `{"amount": 1}`).Code)
+	assert.Equal(t, http.StatusConflict, post("/managers/web/approve", `{"id": 1}`).Code)
+	assert.Equal(t, http.StatusConflict, post("/managers/web/reject", "").Code)
+	assert.Equal(t, http.StatusNotFound, post("/managers/web/explode", "").Code)
+	assert.Equal(t, http.StatusNotFound, post("/managers/missing/pause", "").Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/managers/web/pause", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServer_ControlAuthorisation(t *testing.T) {
+	server := setupServerTest()
+	post := func(server *alice.Server, authorization string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/managers/web/force", strings.NewReader(`{"amount": 1}`))
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		server.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, post(server, ""))
+	assert.Equal(t, http.StatusUnauthorized, post(server, "Bearer guess"))
+	assert.Equal(t, http.StatusOK, post(server, "Bearer secret"))
+	for _, control := range []string{"approve", "reject"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", "/managers/web/"+control, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// Without a token nothing can be controlled, but the status is still available
+	open := alice.NewServer(func() []*alice.Manager { return nil }, func(*alice.Manager) time.Duration { return time.Minute }, "", log)
+	assert.Equal(t, http.StatusForbidden, post(open, "Bearer "))
+	w := httptest.NewRecorder()
+	open.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
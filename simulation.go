@@ -0,0 +1,328 @@
+package alice
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Sample is a set of metric readings recorded at a point in time, for replaying through a Simulation. Total is the
+// size the inventory was when the readings were recorded, if known.
+type Sample struct {
+	Time    time.Time          `json:"time"`
+	Total   *int               `json:"total,omitempty"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// ReadSamplesCSV reads samples from CSV with a header row. The 'time' column (RFC3339 or unix seconds) is required, an
+// optional 'total' column gives the inventory size at the time, and every other column is a metric. Samples are
+// returned in time order.
+func ReadSamplesCSV(r io.Reader) ([]Sample, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("No header row")
+	}
+	header := rows[0]
+	timeColumn := -1
+	for i, name := range header {
+		if name == "time" {
+			timeColumn = i
+		}
+	}
+	if timeColumn < 0 {
+		return nil, errors.New("No time column")
+	}
+	samples := make([]Sample, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		sample := Sample{Metrics: map[string]float64{}}
+		for i, value := range row {
+			switch header[i] {
+			case "time":
+				sample.Time, err = parseSampleTime(value)
+			case "total":
+				var total int
+				total, err = strconv.Atoi(value)
+				sample.Total = &total
+			default:
+				sample.Metrics[header[i]], err = strconv.ParseFloat(value, 64)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "Line %d, column %s", line+2, header[i])
+			}
+		}
+		samples = append(samples, sample)
+	}
+	sortSamples(samples)
+	return samples, nil
+}
+
+// ReadSamplesJSON reads samples written as one JSON object per line, eg
+//
+//	{"time": "2017-11-24T09:00:00Z", "total": 4, "metrics": {"cpu": 62.5}}
+//
+// Samples are returned in time order.
+func ReadSamplesJSON(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, errors.Wrapf(err, "Line %d", line)
+		}
+		if sample.Time.IsZero() {
+			return nil, errors.Errorf("Line %d has no time", line)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sortSamples(samples)
+	return samples, nil
+}
+
+func parseSampleTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func sortSamples(samples []Sample) {
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+}
+
+// SimulationStep is what happened at one run of a simulated manager
+type SimulationStep struct {
+	Time           time.Time       `json:"time"`
+	Metrics        []MetricUpdate  `json:"metrics"`
+	Recommendation *Recommendation `json:"recommendation"`
+	Evaluation     *Evaluation     `json:"evaluation,omitempty"`
+	Action         Action          `json:"action"`
+	Total          int             `json:"total"`
+	Ready          int             `json:"ready"`
+}
+
+// SimulationResult is the outcome of a simulation
+type SimulationResult struct {
+	Steps      []SimulationStep `json:"steps"`
+	ScaleUps   int              `json:"scale_ups"`
+	ScaleDowns int              `json:"scale_downs"`
+	MinTotal   int              `json:"min_total"`
+	MaxTotal   int              `json:"max_total"`
+}
+
+// Simulation replays recorded metrics through a manager's strategy, with a virtual clock and a simulated inventory in
+// place of the real ones, so that strategies can be tuned without touching anything in production. Everything else
+// about the manager (confirmations, step caps, limits, schedules) works as configured, except that scaling is never
+// advisory.
+//
+// The simulated inventory starts at InitialTotal. Resources added take ProvisionDelay to become ready, and the
+// inventory won't scale again until they are. Metrics named in PerResource are treated as load per resource, so
+// their readings are scaled by the size of the inventory when they were recorded over the number of ready resources
+// in the simulation.
+type Simulation struct {
+	InitialTotal   int
+	ProvisionDelay time.Duration
+	PerResource    []string
+	Interval       time.Duration // How often the manager runs, defaulting to its interval or 2m
+	manager        *Manager
+	inventory      *simulatedInventory
+	monitor        *simulatedMonitor
+}
+
+// NewSimulation creates a simulation of the manager configured by config. Only its strategy is created, so none of its
+// plugins talk to remote services.
+func NewSimulation(name string, config *viper.Viper, log *logrus.Entry) (*Simulation, error) {
+	if !config.IsSet("strategy") {
+		return nil, errors.New("Missing strategy definition")
+	}
+	s := &Simulation{InitialTotal: 1, Interval: defaultInterval}
+	if config.IsSet("interval") {
+		s.Interval = config.GetDuration("interval")
+	}
+	s.inventory = &simulatedInventory{sim: s}
+	s.monitor = &simulatedMonitor{sim: s}
+	mon := &monitorRecorder{Monitor: s.monitor}
+	str, err := NewStrategy(config.Sub("strategy"), s.inventory, mon, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error initializing strategy")
+	}
+	windows, err := newWindows(config)
+	if err != nil {
+		return nil, err
+	}
+	config.Set("scale_up", true)
+	config.Set("scale_down", true)
+	s.manager = &Manager{Name: name, Strategy: str, Inventory: s.inventory, Monitor: mon, Logger: log, Config: config,
+		windows: windows, Clock: func() time.Time { return s.inventory.now }}
+	return s, nil
+}
+
+// Run replays samples through the manager, running it every Interval from the time of the first sample to the last.
+// Each run sees the most recent sample.
+func (s *Simulation) Run(ctx context.Context, samples []Sample) (*SimulationResult, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("No samples to simulate")
+	}
+	if s.Interval <= 0 {
+		return nil, errors.New("Interval must be positive")
+	}
+	if s.InitialTotal < 0 {
+		return nil, errors.New("Initial total can't be negative")
+	}
+	s.inventory.reset(samples[0].Time, s.InitialTotal)
+	result := &SimulationResult{MinTotal: s.InitialTotal, MaxTotal: s.InitialTotal}
+	next := 0
+	for now := samples[0].Time; !now.After(samples[len(samples)-1].Time); now = now.Add(s.Interval) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for next < len(samples) && !samples[next].Time.After(now) {
+			s.monitor.sample = samples[next]
+			next++
+		}
+		s.inventory.advance(now)
+		before := s.inventory.total
+		s.manager.Run(ctx)
+		status := s.manager.Status()
+		step := SimulationStep{
+			Time:           now,
+			Metrics:        status.Metrics,
+			Recommendation: status.Recommendation,
+			Evaluation:     status.Evaluation,
+			Action:         status.Action,
+			Total:          s.inventory.total,
+			Ready:          s.inventory.ready(),
+		}
+		switch {
+		case step.Total > before:
+			result.ScaleUps++
+		case step.Total < before:
+			result.ScaleDowns++
+		}
+		if step.Total < result.MinTotal {
+			result.MinTotal = step.Total
+		}
+		if step.Total > result.MaxTotal {
+			result.MaxTotal = step.Total
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	return result, nil
+}
+
+// simulatedInventory is an Inventory whose new resources take a while to become ready. Like real inventories it
+// won't scale while it is UPDATING.
+type simulatedInventory struct {
+	sim     *Simulation
+	now     time.Time
+	total   int
+	pending []time.Time // When each resource being provisioned will be ready
+}
+
+func (i *simulatedInventory) reset(now time.Time, total int) {
+	i.now, i.total, i.pending = now, total, nil
+}
+
+// advance moves the clock on to now, and finishes provisioning any resources that are ready by then
+func (i *simulatedInventory) advance(now time.Time) {
+	i.now = now
+	for len(i.pending) > 0 && !i.pending[0].After(now) {
+		i.pending = i.pending[1:]
+	}
+}
+
+func (i *simulatedInventory) ready() int {
+	return i.total - len(i.pending)
+}
+
+func (i *simulatedInventory) Total(_ context.Context) (int, error) {
+	return i.total, nil
+}
+
+func (i *simulatedInventory) Increase(_ context.Context, amount int) error {
+	if len(i.pending) > 0 {
+		return errors.New("Won't scale while resources are being provisioned")
+	}
+	i.total += amount
+	if i.sim.ProvisionDelay > 0 {
+		for n := 0; n < amount; n++ {
+			i.pending = append(i.pending, i.now.Add(i.sim.ProvisionDelay))
+		}
+	}
+	return nil
+}
+
+func (i *simulatedInventory) Decrease(_ context.Context, amount int) error {
+	if len(i.pending) > 0 {
+		return errors.New("Won't scale while resources are being provisioned")
+	}
+	if amount > i.total {
+		return errors.New("Won't scale below zero resources")
+	}
+	i.total -= amount
+	return nil
+}
+
+func (i *simulatedInventory) Status(_ context.Context) (Status, error) {
+	if len(i.pending) > 0 {
+		return UPDATING, nil
+	}
+	return OK, nil
+}
+
+// simulatedMonitor returns readings from the current sample
+type simulatedMonitor struct {
+	sim    *Simulation
+	sample Sample
+}
+
+func (m *simulatedMonitor) GetUpdatedMetrics(_ context.Context, names []string) (*[]MetricUpdate, error) {
+	updates := make([]MetricUpdate, len(names))
+	for i, name := range names {
+		reading, ok := m.sample.Metrics[name]
+		if !ok {
+			return nil, errors.Errorf("No recorded readings for %s", name)
+		}
+		updates[i] = MetricUpdate{Name: name, CurrentReading: m.perResource(name, reading), Timestamp: m.sample.Time}
+	}
+	return &updates, nil
+}
+
+// perResource scales a reading of load per resource from the size of the inventory it was recorded with to the
+// number of resources ready in the simulation
+func (m *simulatedMonitor) perResource(name string, reading float64) float64 {
+	found := false
+	for _, n := range m.sim.PerResource {
+		found = found || n == name
+	}
+	if !found {
+		return reading
+	}
+	recorded := m.sim.InitialTotal
+	if m.sample.Total != nil {
+		recorded = *m.sample.Total
+	}
+	ready := m.sim.inventory.ready()
+	if ready < 1 {
+		ready = 1
+	}
+	return reading * float64(recorded) / float64(ready)
+}
@@ -0,0 +1,85 @@
+package alice_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	alice.RegisterStrategy("threshold", alice.NewThresholdStrategy)
+}
+
+const recordedCSV = `time,total,load
+2017-11-24T09:10:00Z,2,50
+2017-11-24T09:00:00Z,2,90
+2017-11-24T09:20:00Z,2,20
+`
+
+func TestReadSamplesCSV(t *testing.T) {
+	samples, err := alice.ReadSamplesCSV(strings.NewReader(recordedCSV))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 3)
+	assert.Equal(t, time.Date(2017, 11, 24, 9, 0, 0, 0, time.UTC), samples[0].Time)
+	assert.Equal(t, 2, *samples[0].Total)
+	assert.Equal(t, 90.0, samples[0].Metrics["load"])
+
+	_, err = alice.ReadSamplesCSV(strings.NewReader("load\n1\n"))
+	assert.Error(t, err)
+	_, err = alice.ReadSamplesCSV(strings.NewReader("time,load\n1511514000,high\n"))
+	assert.Error(t, err)
+}
+
+func TestReadSamplesJSON(t *testing.T) {
+	samples, err := alice.ReadSamplesJSON(strings.NewReader(`{"time": "2017-11-24T09:05:00Z", "metrics": {"load": 10}}
+
+{"time": "2017-11-24T09:00:00Z", "metrics": {"load": 20}}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+	assert.Equal(t, 20.0, samples[0].Metrics["load"])
+	assert.Nil(t, samples[0].Total)
+
+	_, err = alice.ReadSamplesJSON(strings.NewReader(`{"metrics": {"load": 10}}`))
+	assert.Error(t, err)
+}
+
+func TestSimulation_Run(t *testing.T) {
+	config := viper.New()
+	config.Set("interval", "5m")
+	config.Set("scale_up", false)
+	config.Set("strategy.name", "threshold")
+	config.Set("strategy.thresholds.load.min", 30)
+	config.Set("strategy.thresholds.load.max", 60)
+	sim, err := alice.NewSimulation("web", config, log)
+	assert.NoError(t, err)
+	sim.InitialTotal = 2
+	sim.ProvisionDelay = 10 * time.Minute
+	sim.PerResource = []string{"load"}
+
+	samples, _ := alice.ReadSamplesCSV(strings.NewReader(recordedCSV))
+	result, err := sim.Run(ctx, samples)
+	assert.NoError(t, err)
+	assert.Len(t, result.Steps, 5)
+
+	// 9:00 scale up despite scale_up being false, 9:05 wait for the new resource, 9:10 hold as load is spread over 3,
+	// 9:15 hold, 9:20 scale down
+	assert.Equal(t, alice.ActionScaled, result.Steps[0].Action)
+	assert.Equal(t, 3, result.Steps[0].Total)
+	assert.Equal(t, 2, result.Steps[0].Ready)
+	assert.Equal(t, alice.ActionRefused, result.Steps[1].Action)
+	assert.InDelta(t, 33.3, result.Steps[2].Metrics[0].CurrentReading, 0.1)
+	assert.Equal(t, alice.ActionNone, result.Steps[2].Action)
+	assert.Equal(t, 2, result.Steps[4].Total)
+	assert.Equal(t, 1, result.ScaleUps)
+	assert.Equal(t, 1, result.ScaleDowns)
+	assert.Equal(t, 2, result.MinTotal)
+	assert.Equal(t, 3, result.MaxTotal)
+
+	_, err = sim.Run(ctx, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package alice
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// SlackNotifier posts the message of each event to a Slack channel through an incoming webhook
+type SlackNotifier struct {
+	log    *logrus.Entry
+	config SlackConfig
+	client *http.Client
+}
+
+// SlackConfig is the configuration of a SlackNotifier
+type SlackConfig struct {
+	HookURL  string        `config:"hook_url" required:"true" description:"URL of the Slack incoming webhook"`
+	Channel  string        `config:"channel" description:"Channel to post to (default the webhook's own)"`
+	Username string        `config:"username" default:"alice" description:"Name to post as"`
+	Emoji    string        `config:"emoji" default:":robot_face:" description:"Emoji to use as the icon"`
+	Timeout  time.Duration `config:"timeout" default:"10s" description:"Give up on a request after this long"`
+}
+
+// slackMessage is the payload of a Slack incoming webhook
+type slackMessage struct {
+	Text      string `json:"text"`
+	Channel   string `json:"channel,omitempty"`
+	Username  string `json:"username,omitempty"`
+	IconEmoji string `json:"icon_emoji,omitempty"`
+}
+
+// NewSlackNotifier creates a new Notifier
+func NewSlackNotifier(config *viper.Viper, log *logrus.Entry) (Notifier, error) {
+	var c SlackConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	return &SlackNotifier{log: log, config: c, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+// Notify posts the event's message
+func (s *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	s.log.Debugf("Posting %s event to Slack", event.Type)
+	return postJSON(ctx, s.client, s.config.HookURL, nil, slackMessage{
+		Text:      event.Message,
+		Channel:   s.config.Channel,
+		Username:  s.config.Username,
+		IconEmoji: s.config.Emoji,
+	})
+}
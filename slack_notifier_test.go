@@ -0,0 +1,33 @@
+package alice_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	config := viper.New()
+	config.Set("hook_url", server.URL)
+	config.Set("channel", "#ops")
+	notifier, err := alice.NewSlackNotifier(config, log)
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Notify(ctx, alice.Event{Type: alice.EventScaledDown, Message: "Scaled web down by 1"}))
+	assert.Equal(t, map[string]string{
+		"text":       "Scaled web down by 1",
+		"channel":    "#ops",
+		"username":   "alice",
+		"icon_emoji": ":robot_face:",
+	}, received)
+}
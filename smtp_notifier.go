@@ -0,0 +1,124 @@
+package alice
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// SMTPNotifier emails the message of each event. The connection is upgraded to TLS if the server supports it.
+type SMTPNotifier struct {
+	log     *logrus.Entry
+	config  SMTPConfig
+	subject *template.Template
+}
+
+// SMTPConfig is the configuration of an SMTPNotifier
+type SMTPConfig struct {
+	Host     string        `config:"host" required:"true" description:"SMTP server to send email through"`
+	Port     int           `config:"port" default:"25" description:"Port of the SMTP server"`
+	Username string        `config:"username" description:"Username to authenticate with, if the server needs it"`
+	Password string        `config:"password" description:"Password to authenticate with"`
+	From     string        `config:"from" required:"true" description:"Address to send email from"`
+	To       []string      `config:"to" required:"true" description:"Addresses to send email to"`
+	Subject  string        `config:"subject" default:"[alice] {{.Manager}}: {{.Type}}" description:"Template for the subject line, given the event"`
+	Timeout  time.Duration `config:"timeout" default:"10s" description:"Give up on sending an email after this long"`
+}
+
+// NewSMTPNotifier creates a new Notifier
+func NewSMTPNotifier(config *viper.Viper, log *logrus.Entry) (Notifier, error) {
+	var c SMTPConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	subject, err := template.New("subject").Parse(c.Subject)
+	if err != nil {
+		return nil, configErrorf("subject", "Invalid template: %s", err.Error())
+	}
+	return &SMTPNotifier{log: log, config: c, subject: subject}, nil
+}
+
+// Validate checks there is someone to send email to
+func (s *SMTPNotifier) Validate() []error {
+	if len(s.config.To) == 0 {
+		return []error{configErrorf("to", "Must list at least one address")}
+	}
+	return nil
+}
+
+// Notify emails the event's message
+func (s *SMTPNotifier) Notify(ctx context.Context, event Event) error {
+	var subject bytes.Buffer
+	if err := s.subject.Execute(&subject, event); err != nil {
+		return errors.Wrap(err, "Can't write subject")
+	}
+	s.log.Debugf("Emailing %s event to %s", event.Type, strings.Join(s.config.To, ", "))
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, fmt.Sprint(s.config.Port)))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.config.From); err != nil {
+		return err
+	}
+	for _, to := range s.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(subject.String(), event)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message writes the email for an event
+func (s *SMTPNotifier) message(subject string, event Event) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.Replace(subject, "\n", " ", -1))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(event.Message, "\n", "\r\n", -1))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
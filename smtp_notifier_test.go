@@ -0,0 +1,96 @@
+package alice_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts a single email, and sends what it received down the returned channel
+func fakeSMTPServer(t *testing.T) (net.Listener, chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("220 localhost ready\r\n"))
+		data := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			switch {
+			case data && line == ".":
+				data = false
+				conn.Write([]byte("250 OK\r\n"))
+			case data:
+			case strings.HasPrefix(line, "DATA"):
+				data = true
+				conn.Write([]byte("354 Go ahead\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				conn.Write([]byte("221 Bye\r\n"))
+				received <- lines
+				return
+			default:
+				conn.Write([]byte("250 OK\r\n"))
+			}
+		}
+		received <- lines
+	}()
+	return listener, received
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	listener, received := fakeSMTPServer(t)
+	defer listener.Close()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	config := viper.New()
+	config.Set("host", host)
+	config.Set("port", port)
+	config.Set("from", "alice@example.com")
+	config.Set("to", []string{"ops@example.com", "web@example.com"})
+	notifier, err := alice.NewSMTPNotifier(config, log)
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Notify(ctx, alice.Event{Type: alice.EventRefused, Manager: "web", Message: "Couldn't scale web"}))
+
+	lines := strings.Join(<-received, "\n")
+	assert.Contains(t, lines, "MAIL FROM:<alice@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<ops@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<web@example.com>")
+	assert.Contains(t, lines, "Subject: [alice] web: refused")
+	assert.Contains(t, lines, "\nCouldn't scale web\n")
+}
+
+func TestSMTPNotifier_Validate(t *testing.T) {
+	config := viper.New()
+	config.Set("host", "localhost")
+	config.Set("from", "alice@example.com")
+	_, err := alice.NewSMTPNotifier(config, log)
+	assert.EqualError(t, err, "to: Must be set")
+
+	config.Set("to", []string{})
+	notifier, err := alice.NewSMTPNotifier(config, log)
+	assert.NoError(t, err)
+	assert.Len(t, notifier.(alice.Validator).Validate(), 1)
+
+	config.Set("subject", "{{.Manager")
+	_, err = alice.NewSMTPNotifier(config, log)
+	assert.Error(t, err)
+}
@@ -1,8 +1,13 @@
 package alice
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -12,21 +17,136 @@ import (
 // and come to a decision about whether an inventory of resources needs to be increased or decreased.
 // It doesn't make any changes, and only provides a recommendation to a Manager.
 type Strategy interface {
-	Evaluate() (*Recommendation, error)
+	Evaluate(ctx context.Context) (*Recommendation, error)
 }
 
-// Recommendation is the return type representing the action the strategy recommends the Manager take
+// TargetStrategy is implemented by strategies that can work out exactly how big the inventory should be, rather than
+// only which direction it should move in. A Manager whose strategy is configured with 'mode: target' will move the
+// inventory straight to the target instead of one step per run.
+type TargetStrategy interface {
+	Strategy
+	Target(ctx context.Context) (int, error)
+}
+
+// MetricStrategy is implemented by strategies that can say which metrics they will ask their monitor for
+type MetricStrategy interface {
+	Strategy
+	Metrics() []string
+}
+
+// CombinedStrategy is implemented by strategies that combine the recommendations of other strategies, and can say
+// what each of them recommended the last time they were evaluated
+type CombinedStrategy interface {
+	Strategy
+	Recommendations() []StrategyRecommendation
+}
+
+// ExplainedStrategy is implemented by strategies that can explain, metric by metric, how they came to the
+// recommendation they made the last time they were evaluated
+type ExplainedStrategy interface {
+	Strategy
+	Explain() *Evaluation
+}
+
+// Evaluation explains a strategy's recommendation. In target mode Target is set to the total the strategy asked for,
+// rather than Recommendation.
+type Evaluation struct {
+	Recommendation Recommendation     `json:"recommendation"`
+	Target         *int               `json:"target,omitempty"`
+	Metrics        []MetricEvaluation `json:"metrics"`
+	Deciding       string             `json:"deciding,omitempty"` // The metric whose recommendation won
+}
+
+// MetricEvaluation is how a strategy judged a single metric. Only the settings the strategy uses are set.
+type MetricEvaluation struct {
+	Name           string         `json:"name"`
+	Reading        float64        `json:"reading"`
+	Min            *float64       `json:"min,omitempty"`
+	Max            *float64       `json:"max,omitempty"`
+	Ratio          string         `json:"ratio,omitempty"`  // Metric to inventory, eg 100:1
+	Target         *int           `json:"target,omitempty"` // The inventory total the metric asks for
+	Recommendation Recommendation `json:"recommendation"`
+	Reason         string         `json:"reason"`
+}
+
+func (e Evaluation) String() string {
+	for _, metric := range e.Metrics {
+		if metric.Name == e.Deciding {
+			return metric.Reason
+		}
+	}
+	return ""
+}
+
+// decide records which metric's recommendation won: the first whose recommendation (or target) matches the final one
+func (e *Evaluation) decide() {
+	for _, metric := range e.Metrics {
+		if (e.Target == nil && metric.Recommendation == e.Recommendation) || (e.Target != nil && metric.Target != nil && *metric.Target == *e.Target) {
+			e.Deciding = metric.Name
+			return
+		}
+	}
+}
+
+// lastEvaluation keeps the explanation of a strategy's last evaluation, for strategies to embed
+type lastEvaluation struct {
+	mu         sync.Mutex
+	evaluation *Evaluation
+}
+
+// Explain returns the explanation of the last evaluation, or nil if it failed
+func (l *lastEvaluation) Explain() *Evaluation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.evaluation
+}
+
+func (l *lastEvaluation) remember(evaluation *Evaluation) {
+	if evaluation != nil {
+		evaluation.decide()
+	}
+	l.mu.Lock()
+	l.evaluation = evaluation
+	l.mu.Unlock()
+}
+
+// formatReading writes a metric reading or setting without an exponent
+func formatReading(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Recommendation is the return type representing the action the strategy recommends the Manager take. Its sign gives
+// the direction and its magnitude the number of resources to add or remove, so Recommendation(4) means "scale up by 4".
 type Recommendation int
 
 const (
-	// SCALEDOWN - we have too much inventory, decrease it
+	// SCALEDOWN - we have too much inventory, decrease it by one
 	SCALEDOWN Recommendation = iota - 1
 	// HOLD - the inventory is just right
 	HOLD
-	// SCALEUP - we have too little inventory, increase it
+	// SCALEUP - we have too little inventory, increase it by one
 	SCALEUP
 )
 
+// Step returns the number of resources the recommendation asks to add or remove, regardless of direction.
+func (r Recommendation) Step() int {
+	if r < HOLD {
+		return int(-r)
+	}
+	return int(r)
+}
+
+// direction returns "up", "down" or "hold"
+func (r Recommendation) direction() string {
+	switch {
+	case r > HOLD:
+		return "up"
+	case r < HOLD:
+		return "down"
+	}
+	return "hold"
+}
+
 // Create a hash for storing the names of registered strategies and their New() methods
 // eg {'foo': foo.New(), 'bar': bar.New(), 'baz': baz.New()}
 type strategyFactoryFunc func(config *viper.Viper, inv Inventory, mon Monitor, log *logrus.Entry) (Strategy, error)
@@ -46,8 +166,9 @@ func RegisterStrategy(name string, factory strategyFactoryFunc) {
 	strategies[name] = factory
 }
 
-// NewStrategy will take a generic block of configuration and read look for a 'name' key, and immediately pass
-// the block of config to the factory function that has been registered with that name.
+// NewStrategy will take a generic block of configuration and read look for a 'name' key, and pass the
+// block of config to the factory function that has been registered with that name. If the plugin has registered a
+// config struct with RegisterConfig, the config is checked against it first.
 func NewStrategy(config *viper.Viper, inv Inventory, mon Monitor, log *logrus.Entry) (Strategy, error) {
 	// Find the correct monitor and return it
 	if !config.IsSet("name") {
@@ -56,11 +177,20 @@ func NewStrategy(config *viper.Viper, inv Inventory, mon Monitor, log *logrus.En
 	name := config.GetString("name")
 	newFunc, ok := strategies[name]
 	if !ok {
-		available := make([]string, len(strategies))
-		for s := range strategies {
-			available = append(available, s)
-		}
-		log.Fatalf("Invalid strategy name. Must be one of: %s", strings.Join(available, ", "))
+		return nil, fmt.Errorf("Invalid strategy name %s. Must be one of: %s", name, strings.Join(Strategies(), ", "))
+	}
+	if err := checkConfig(StrategyPlugin, name, config); err != nil {
+		return nil, err
 	}
 	return newFunc(config, inv, mon, log.WithField("strategy", name))
 }
+
+// Strategies returns the names of the registered strategies, sorted
+func Strategies() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,6 +1,7 @@
 package alice_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Sirupsen/logrus"
@@ -14,11 +15,29 @@ type MockStrategy struct {
 	mock.Mock
 }
 
-func (m *MockStrategy) Evaluate() (*alice.Recommendation, error) {
+func (m *MockStrategy) Evaluate(_ context.Context) (*alice.Recommendation, error) {
 	args := m.Mock.Called()
 	return args.Get(0).(*alice.Recommendation), args.Error(1)
 }
 
+type MockTargetStrategy struct {
+	MockStrategy
+}
+
+func (m *MockTargetStrategy) Target(_ context.Context) (int, error) {
+	args := m.Mock.Called()
+	return args.Int(0), args.Error(1)
+}
+
+type MockExplainedStrategy struct {
+	MockStrategy
+}
+
+func (m *MockExplainedStrategy) Explain() *alice.Evaluation {
+	args := m.Mock.Called()
+	return args.Get(0).(*alice.Evaluation)
+}
+
 func NewMockStrategy(_ *viper.Viper, _ alice.Inventory, _ alice.Monitor, _ *logrus.Entry) (alice.Strategy, error) {
 	return &MockStrategy{}, nil
 }
@@ -42,4 +61,5 @@ func TestNewStrategy(t *testing.T) {
 	setupStrategyTest()
 	s, _ := alice.NewStrategy(config, mockInventory, mockMonitor, log)
 	assert.IsType(t, &MockStrategy{}, s)
+	assert.Contains(t, alice.Strategies(), "mock")
 }
@@ -0,0 +1,278 @@
+package alice
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const defaultInterval = 2 * time.Minute
+
+// BuildFunc creates a Manager with the given name from its block of configuration
+type BuildFunc func(name string, config *viper.Viper) (*Manager, error)
+
+// Supervisor keeps the set of running managers in step with the 'managers' section of the configuration. When the
+// configuration is loaded again only managers whose configuration has changed are rebuilt, so untouched managers keep
+// their state (settle down periods, confirmation windows etc).
+//
+// Once started, every manager runs on its own schedule, every 'interval' plus a random delay of up to 'jitter'. Both
+// can be set for each manager, and otherwise come from the top level of the configuration. A slow manager only
+// delays its own next run.
+type Supervisor struct {
+	build    BuildFunc
+	log      *logrus.Entry
+	loading  sync.Mutex
+	mu       sync.RWMutex
+	managers map[string]*Manager
+	settings map[string]interface{}
+	interval time.Duration
+	jitter   time.Duration
+	ctx      context.Context // Set while the managers are running
+	loops    map[string]*loop
+	selected []string
+}
+
+// loop is the schedule a single manager runs on. Closing stop ends it after any run in progress, then done is closed.
+type loop struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSupervisor creates a new Supervisor with no managers. Managers are created with build.
+func NewSupervisor(build BuildFunc, log *logrus.Entry) *Supervisor {
+	return &Supervisor{
+		build:    build,
+		log:      log,
+		managers: map[string]*Manager{},
+		settings: map[string]interface{}{},
+		interval: defaultInterval,
+		loops:    map[string]*loop{},
+	}
+}
+
+// Select restricts the supervisor to the named managers, ignoring any others in the configuration. It takes effect
+// the next time the configuration is loaded. With no names, every manager is used.
+func (s *Supervisor) Select(names ...string) {
+	s.loading.Lock()
+	defer s.loading.Unlock()
+	s.selected = names
+}
+
+// Load builds managers from config, replacing any whose configuration has changed and removing any that no longer
+// appear. Every new or changed manager is built before anything is replaced, so if any of them fail the error is
+// returned and the managers already running are left alone.
+func (s *Supervisor) Load(config *viper.Viper) error {
+	if !config.IsSet("managers") {
+		return errors.New("No managers defined")
+	}
+	names := config.GetStringMap("managers")
+	interval := defaultInterval
+	if config.IsSet("interval") {
+		interval = config.GetDuration("interval")
+	}
+	if interval <= 0 {
+		return errors.Errorf("Invalid interval %v", config.Get("interval"))
+	}
+
+	// Only Load changes the managers, so holding loading is enough to read them while building
+	s.loading.Lock()
+	defer s.loading.Unlock()
+	if len(s.selected) > 0 {
+		all := names
+		names = map[string]interface{}{}
+		for _, name := range s.selected {
+			if _, ok := all[name]; !ok {
+				return errors.Errorf("No manager called %s", name)
+			}
+			names[name] = all[name]
+		}
+	}
+	built := map[string]*Manager{}
+	for name := range names {
+		settings := config.Get("managers." + name)
+		if reflect.DeepEqual(settings, s.settings[name]) && s.managers[name] != nil {
+			continue
+		}
+		managerConfig := config.Sub("managers." + name)
+		if managerConfig == nil {
+			return errors.Errorf("Manager %s has no configuration", name)
+		}
+		if managerConfig.IsSet("interval") && managerConfig.GetDuration("interval") <= 0 {
+			return errors.Errorf("Manager %s has an invalid interval %v", name, managerConfig.Get("interval"))
+		}
+		mgr, err := s.build(name, managerConfig)
+		if err != nil {
+			return errors.Wrapf(err, "Error initializing manager %s", name)
+		}
+		built[name] = mgr
+	}
+
+	// The managers being replaced finish any run in progress before their state is handed on, so none of it is
+	// changed after it has been copied. Their loops need s.mu to finish, so they are waited for without it.
+	s.mu.Lock()
+	var stopped []chan struct{}
+	for name := range built {
+		if done := s.stopLoop(name); done != nil {
+			stopped = append(stopped, done)
+		}
+	}
+	s.mu.Unlock()
+	for _, done := range stopped {
+		<-done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval, s.jitter = interval, config.GetDuration("jitter")
+	for name := range s.managers {
+		if _, ok := names[name]; !ok {
+			s.log.Infof("Removing manager %s", name)
+			s.stopLoop(name)
+			delete(s.managers, name)
+			delete(s.settings, name)
+		}
+	}
+	for name, mgr := range built {
+		if previous, ok := s.managers[name]; ok {
+			s.log.Infof("Replacing manager %s", name)
+			mgr.inherit(previous)
+		} else {
+			s.log.Infof("Adding manager %s", name)
+		}
+		// Only a loop started since the others were stopped can still be running
+		previous := s.stopLoop(name)
+		s.managers[name] = mgr
+		s.settings[name] = config.Get("managers." + name)
+		if s.ctx != nil {
+			s.startLoop(mgr, previous)
+		}
+	}
+	return nil
+}
+
+// Start runs every manager on its own schedule until Stop is called. Managers added by later calls to Load are
+// started as they are added. Runs are given ctx, so cancelling it abandons any runs in progress.
+func (s *Supervisor) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx = ctx
+	for _, mgr := range s.managers {
+		s.startLoop(mgr, nil)
+	}
+}
+
+// Stop stops starting new runs, and waits for any runs in progress to finish
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	var stopped []chan struct{}
+	for name := range s.loops {
+		stopped = append(stopped, s.stopLoop(name))
+	}
+	s.ctx = nil
+	s.mu.Unlock()
+	for _, done := range stopped {
+		<-done
+	}
+}
+
+// Managers returns the running managers, sorted by name
+func (s *Supervisor) Managers() []*Manager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.managers))
+	for name := range s.managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	managers := make([]*Manager, len(names))
+	for i, name := range names {
+		managers[i] = s.managers[name]
+	}
+	return managers
+}
+
+// startLoop starts running mgr on its schedule, once the loop of the manager it replaces (if any) has finished so that
+// the two never run at the same time. s.mu must be held.
+func (s *Supervisor) startLoop(mgr *Manager, previous chan struct{}) {
+	l := &loop{stop: make(chan struct{}), done: make(chan struct{})}
+	s.loops[mgr.Name] = l
+	ctx := s.ctx
+	go func() {
+		defer close(l.done)
+		if previous != nil {
+			<-previous
+		}
+		s.run(ctx, mgr, l.stop)
+	}()
+}
+
+// stopLoop stops the named manager's loop, if it has one, and returns a channel that is closed once it has finished.
+// s.mu must be held.
+func (s *Supervisor) stopLoop(name string) chan struct{} {
+	l, ok := s.loops[name]
+	if !ok {
+		return nil
+	}
+	close(l.stop)
+	delete(s.loops, name)
+	return l.done
+}
+
+// run runs mgr every interval until stop is closed. The interval is measured from the start of each run, so runs
+// that take a while don't push back the ones after them, and a run that overruns is followed straight away by the next.
+func (s *Supervisor) run(ctx context.Context, mgr *Manager, stop chan struct{}) {
+	timer := time.NewTimer(s.delay(mgr, 0))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		started := time.Now()
+		mgr.Run(ctx)
+		timer.Reset(s.delay(mgr, s.Interval(mgr)-time.Since(started)))
+	}
+}
+
+// delay adds a random amount of up to the manager's jitter to wait
+func (s *Supervisor) delay(mgr *Manager, wait time.Duration) time.Duration {
+	s.mu.RLock()
+	jitter := s.jitter
+	s.mu.RUnlock()
+	if mgr.Config.IsSet("jitter") {
+		jitter = mgr.Config.GetDuration("jitter")
+	}
+	if jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Interval is how often mgr runs, either its own interval or the one for every manager
+func (s *Supervisor) Interval(mgr *Manager) time.Duration {
+	if mgr.Config.IsSet("interval") {
+		return mgr.Config.GetDuration("interval")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.interval
+}
@@ -0,0 +1,240 @@
+package alice_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupSupervisorTest() (*alice.Supervisor, *int) {
+	builds := 0
+	build := func(name string, config *viper.Viper) (*alice.Manager, error) {
+		if config.GetBool("invalid") {
+			return nil, errors.New("Invalid manager")
+		}
+		builds++
+		return &alice.Manager{Name: name, Config: config, Logger: log}, nil
+	}
+	return alice.NewSupervisor(build, log), &builds
+}
+
+func TestSupervisor_Load(t *testing.T) {
+	supervisor, builds := setupSupervisorTest()
+	config := viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web":     map[string]interface{}{"scale_up": true},
+		"workers": map[string]interface{}{"scale_up": true},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	managers := supervisor.Managers()
+	assert.Len(t, managers, 2)
+	assert.Equal(t, "web", managers[0].Name)
+	assert.Equal(t, 2, *builds)
+
+	// Only the changed manager is rebuilt, and removed managers go away
+	config = viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web":    map[string]interface{}{"scale_up": true},
+		"search": map[string]interface{}{"scale_up": false},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	reloaded := supervisor.Managers()
+	assert.Len(t, reloaded, 2)
+	assert.Equal(t, "search", reloaded[0].Name)
+	assert.True(t, managers[0] == reloaded[1], "unchanged manager should be kept")
+	assert.Equal(t, 3, *builds)
+}
+
+func TestSupervisor_Interval(t *testing.T) {
+	supervisor, _ := setupSupervisorTest()
+	config := viper.New()
+	config.Set("interval", "30s")
+	config.Set("managers", map[string]interface{}{
+		"web":     map[string]interface{}{"interval": "10s"},
+		"workers": map[string]interface{}{},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	managers := supervisor.Managers()
+	assert.Equal(t, 10*time.Second, supervisor.Interval(managers[0]))
+	assert.Equal(t, 30*time.Second, supervisor.Interval(managers[1]))
+}
+
+func TestSupervisor_LoadKeepsState(t *testing.T) {
+	supervisor, _ := setupSupervisorTest()
+	config := viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{"scale_up": true},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	old := supervisor.Managers()[0]
+	old.Pause(time.Hour, "Maintenance")
+
+	// A manager replaced because its configuration changed is still paused, and the old one gives up the pause
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{"scale_up": false},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	replaced := supervisor.Managers()[0]
+	assert.False(t, replaced.Config.GetBool("scale_up"))
+	if assert.NotNil(t, replaced.Override()) {
+		assert.Equal(t, alice.OverridePause, replaced.Override().Kind)
+		assert.Equal(t, "Maintenance", replaced.Override().Reason)
+	}
+	assert.Nil(t, old.Override())
+}
+
+func TestSupervisor_LoadInvalid(t *testing.T) {
+	supervisor, _ := setupSupervisorTest()
+	config := viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{"scale_up": true},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	managers := supervisor.Managers()
+
+	config = viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web":    map[string]interface{}{"scale_up": false},
+		"broken": map[string]interface{}{"invalid": true},
+	})
+	assert.Error(t, supervisor.Load(config))
+	assert.Equal(t, managers, supervisor.Managers())
+	assert.True(t, supervisor.Managers()[0].Config.GetBool("scale_up"))
+
+	assert.Error(t, supervisor.Load(viper.New()))
+}
+
+func TestSupervisor_Select(t *testing.T) {
+	supervisor, builds := setupSupervisorTest()
+	config := viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web":     map[string]interface{}{"scale_up": true},
+		"workers": map[string]interface{}{"scale_up": true},
+		"search":  map[string]interface{}{"scale_up": true},
+	})
+	supervisor.Select("workers", "web")
+	assert.NoError(t, supervisor.Load(config))
+	managers := supervisor.Managers()
+	assert.Len(t, managers, 2)
+	assert.Equal(t, "web", managers[0].Name)
+	assert.Equal(t, "workers", managers[1].Name)
+	assert.Equal(t, 2, *builds)
+
+	supervisor.Select("missing")
+	assert.Error(t, supervisor.Load(config))
+	assert.Len(t, supervisor.Managers(), 2)
+}
+
+// setupScheduledManager creates a manager whose strategy always recommends holding. Each run sends the manager on
+// runs, then waits for release to be closed, if it's given.
+func setupScheduledManager(name string, config *viper.Viper, runs chan<- *alice.Manager, release <-chan struct{}) (*alice.Manager, *MockStrategy) {
+	inv := MockInventory{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	str := MockStrategy{}
+	mgr := &alice.Manager{Name: name, Strategy: &str, Inventory: &inv, Logger: log, Config: config}
+	hold := alice.HOLD
+	str.On("Evaluate").Return(&hold, nil).Run(func(mock.Arguments) {
+		runs <- mgr
+		if release != nil {
+			<-release
+		}
+	})
+	return mgr, &str
+}
+
+// nextRun waits for the next manager to run
+func nextRun(t *testing.T, runs <-chan *alice.Manager) *alice.Manager {
+	select {
+	case mgr := <-runs:
+		return mgr
+	case <-time.After(time.Second):
+		t.Fatal("No manager ran")
+		return nil
+	}
+}
+
+// stopScheduledManagers stops the supervisor, letting any runs in progress finish
+func stopScheduledManagers(supervisor *alice.Supervisor, runs chan *alice.Manager) {
+	go func() {
+		for range runs {
+		}
+	}()
+	supervisor.Stop()
+	close(runs)
+}
+
+func TestSupervisor_Start(t *testing.T) {
+	runs, release := make(chan *alice.Manager), make(chan struct{})
+	strategies := map[string]*MockStrategy{}
+	build := func(name string, config *viper.Viper) (*alice.Manager, error) {
+		var blocked <-chan struct{}
+		if config.GetBool("blocked") {
+			blocked = release
+		}
+		mgr, str := setupScheduledManager(name, config, runs, blocked)
+		strategies[name] = str
+		return mgr, nil
+	}
+	supervisor := alice.NewSupervisor(build, log)
+	config := viper.New()
+	config.Set("interval", "10ms")
+	config.Set("managers", map[string]interface{}{
+		"fast": map[string]interface{}{},
+		"slow": map[string]interface{}{"interval": "1h", "blocked": true},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	supervisor.Start(context.Background())
+
+	// The fast manager keeps running on its own interval while the slow one is part way through its first run
+	for mgr := nextRun(t, runs); mgr.Name != "slow"; mgr = nextRun(t, runs) {
+	}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "fast", nextRun(t, runs).Name)
+	}
+	close(release)
+	stopScheduledManagers(supervisor, runs)
+	assert.Len(t, strategies["slow"].Calls, 1)
+}
+
+func TestSupervisor_LoadWhileRunning(t *testing.T) {
+	runs := make(chan *alice.Manager)
+	build := func(name string, config *viper.Viper) (*alice.Manager, error) {
+		mgr, _ := setupScheduledManager(name, config, runs, nil)
+		return mgr, nil
+	}
+	supervisor := alice.NewSupervisor(build, log)
+	config := viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{"interval": "10ms"},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	supervisor.Start(context.Background())
+	old := supervisor.Managers()[0]
+	assert.True(t, nextRun(t, runs) == old)
+
+	// The replacement takes over from the old manager, which stops running
+	config = viper.New()
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{"interval": "5ms"},
+	})
+	assert.NoError(t, supervisor.Load(config))
+	replacement := supervisor.Managers()[0]
+	assert.False(t, replacement == old)
+	for mgr := nextRun(t, runs); mgr != replacement; mgr = nextRun(t, runs) {
+		assert.True(t, mgr == old)
+	}
+	for i := 0; i < 3; i++ {
+		assert.True(t, nextRun(t, runs) == replacement, "old manager should stop running")
+	}
+	stopScheduledManagers(supervisor, runs)
+
+	config.Set("interval", "-1s")
+	assert.Error(t, supervisor.Load(config))
+}
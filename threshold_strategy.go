@@ -1,20 +1,37 @@
 package alice
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 // ThresholdStrategy aims to keep the value in the middle but will always recommend scaling up if any metric
-// is above it's upper threshold
+// is above it's upper threshold. Each threshold may set a 'step' to change the inventory by more than one at a time.
 type ThresholdStrategy struct {
 	Config *viper.Viper
 	// <metric name>: [<lower threshold>, <upper threshold>]
 	Inventory Inventory
 	Monitor   Monitor
 	log       *logrus.Entry
+	lastEvaluation
+}
+
+// ThresholdConfig is the configuration of a ThresholdStrategy
+type ThresholdConfig struct {
+	Thresholds map[string]MetricThresholdConfig `config:"thresholds" required:"true" description:"The thresholds for each metric, by metric name"`
+}
+
+// MetricThresholdConfig is the thresholds a ThresholdStrategy applies to a single metric. At least one of min and
+// max must be set.
+type MetricThresholdConfig struct {
+	Min           *float64 `config:"min" description:"Scale down when the metric is below this"`
+	Max           *float64 `config:"max" description:"Scale up when the metric is above this"`
+	Step          int      `config:"step" default:"1" description:"How many resources to add or remove at a time"`
+	InvertScaling bool     `config:"invert_scaling" description:"Scale up below min and down above max instead"`
 }
 
 // NewThresholdStrategy creates a new Strategy
@@ -22,21 +39,54 @@ func NewThresholdStrategy(config *viper.Viper, inv Inventory, mon Monitor, log *
 	return &ThresholdStrategy{Config: config, Inventory: inv, Monitor: mon, log: log}, nil
 }
 
-// Evaluate will pull data from the associated Monitor and return a scaling recommendation
-func (p *ThresholdStrategy) Evaluate() (*Recommendation, error) {
-	finalRecommendation := SCALEDOWN
+// Metrics returns the names of the metrics the strategy has thresholds for, sorted
+func (p *ThresholdStrategy) Metrics() []string {
+	var names []string
+	for metricName := range p.Config.GetStringMap("thresholds") {
+		names = append(names, metricName)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	var metricNames []string
+// Validate checks every threshold has a min or max, and a valid step
+func (p *ThresholdStrategy) Validate() []error {
+	if !p.Config.IsSet("thresholds") {
+		return []error{configErrorf("thresholds", "Must provide at least one threshold")}
+	}
+	var errs []error
 	for metricName := range p.Config.GetStringMap("thresholds") {
-		metricNames = append(metricNames, metricName)
+		key := "thresholds." + metricName
+		metricConfig := p.Config.Sub(key)
+		if metricConfig == nil || (!metricConfig.IsSet("min") && !metricConfig.IsSet("max")) {
+			errs = append(errs, configErrorf(key, "Threshold strategy needs either 'min' or 'max'"))
+			continue
+		}
+		if metricConfig.IsSet("min") && metricConfig.IsSet("max") && metricConfig.GetFloat64("min") > metricConfig.GetFloat64("max") {
+			errs = append(errs, configErrorf(key+".min", "Must not be more than max"))
+		}
+		if metricConfig.IsSet("step") && metricConfig.GetInt("step") < 1 {
+			errs = append(errs, configErrorf(key+".step", "Must be at least 1"))
+		}
 	}
-	metricUpdates, err := p.Monitor.GetUpdatedMetrics(metricNames)
+	return errs
+}
+
+// Evaluate will pull data from the associated Monitor and return a scaling recommendation, explaining it for Explain
+func (p *ThresholdStrategy) Evaluate(ctx context.Context) (*Recommendation, error) {
+	finalRecommendation := SCALEDOWN
+	first := true
+	p.remember(nil)
+
+	metricUpdates, err := p.Monitor.GetUpdatedMetrics(ctx, p.Metrics())
 	if err != nil {
 		return nil, err
 	}
+	evaluation := &Evaluation{}
 	for _, metric := range *metricUpdates {
 		var metricRecommendation Recommendation
 		var invert = 1
+		var reason string
 
 		if !p.Config.IsSet("thresholds." + metric.Name) {
 			return nil, fmt.Errorf("No threshold configuration for %s", metric.Name)
@@ -46,24 +96,46 @@ func (p *ThresholdStrategy) Evaluate() (*Recommendation, error) {
 		if metricConfig.GetBool("invert_scaling") {
 			invert = -1
 		}
+		metricConfig.SetDefault("step", 1)
+		step := metricConfig.GetInt("step")
+		if step < 1 {
+			return nil, fmt.Errorf("Threshold step for %s must be at least 1", metric.Name)
+		}
 		min := metricConfig.GetFloat64("min")
 		max := metricConfig.GetFloat64("max")
+		reading := formatReading(metric.CurrentReading)
 		switch {
 		case metric.CurrentReading < min && metricConfig.IsSet("min"):
-			metricRecommendation = Recommendation(int(SCALEDOWN) * invert)
+			metricRecommendation = Recommendation(int(SCALEDOWN) * step * invert)
+			reason = fmt.Sprintf("%s is %s, below the min of %s", metric.Name, reading, formatReading(min))
 		case metric.CurrentReading > max && metricConfig.IsSet("max"):
-			metricRecommendation = Recommendation(int(SCALEUP) * invert)
+			metricRecommendation = Recommendation(int(SCALEUP) * step * invert)
+			reason = fmt.Sprintf("%s is %s, above the max of %s", metric.Name, reading, formatReading(max))
 		case !metricConfig.IsSet("max") && !metricConfig.IsSet("min"):
 			return nil, fmt.Errorf("Threshold strategy needs either 'min' or 'max' for %s", metric.Name)
 		default:
 			metricRecommendation = HOLD
+			reason = fmt.Sprintf("%s is %s, within its thresholds", metric.Name, reading)
+		}
+		if invert < 0 && metricRecommendation != HOLD {
+			reason += " (inverted)"
 		}
 		p.log.Debugf("Metric: %v value: %v. Suggests %v.", metric.Name, metric.CurrentReading, metricRecommendation)
-		if finalRecommendation < metricRecommendation { // Worst case scenario wins
+		e := MetricEvaluation{Name: metric.Name, Reading: metric.CurrentReading, Recommendation: metricRecommendation, Reason: reason}
+		if metricConfig.IsSet("min") {
+			e.Min = &min
+		}
+		if metricConfig.IsSet("max") {
+			e.Max = &max
+		}
+		evaluation.Metrics = append(evaluation.Metrics, e)
+		if first || finalRecommendation < metricRecommendation { // Worst case scenario wins
 			finalRecommendation = metricRecommendation
+			first = false
 		}
 	}
 	p.log.Debugf("Recommending %v as safest option", finalRecommendation)
+	evaluation.Recommendation = finalRecommendation
+	p.remember(evaluation)
 	return &finalRecommendation, nil
-
 }
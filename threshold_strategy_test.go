@@ -7,6 +7,7 @@ import (
 	"github.com/notonthehighstreet/alice"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var mockResponse []alice.MetricUpdate
@@ -34,33 +35,33 @@ func TestThresholdStrategy_EvaluateSingleMetric(t *testing.T) {
 	config.Set("thresholds.metric.name.max", 15)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 0}}
-	recommendation, _ := thresholdStrategy.Evaluate()
+	recommendation, _ := thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 10}}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.HOLD)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 20}}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 	setupThresholdStrategyTest()
 	config.Set("thresholds.metric.name.foo", "invalid")
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 0}}
-	_, err := thresholdStrategy.Evaluate()
+	_, err := thresholdStrategy.Evaluate(ctx)
 	assert.Error(t, err)
 
 	config.Set("thresholds.metric.name.min", 5)
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 10}}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.HOLD)
 
 	config.Set("thresholds.metric.name.max", 6)
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 }
 
@@ -72,15 +73,15 @@ func TestThresholdStrategy_EvaluateSingleMetricInverted(t *testing.T) {
 	config.Set("thresholds.metric.name.invert_scaling", true)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 0}}
-	recommendation, _ := thresholdStrategy.Evaluate()
+	recommendation, _ := thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 10}}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.HOLD)
 
 	mockResponse = []alice.MetricUpdate{{Name: "metric.name", CurrentReading: 20}}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 }
 
@@ -96,35 +97,131 @@ func TestThresholdStrategy_EvaluateMultipleMetrics(t *testing.T) {
 		{Name: "metric.one", CurrentReading: 0},
 		{Name: "metric.two", CurrentReading: 0},
 	}
-	recommendation, _ := thresholdStrategy.Evaluate()
+	recommendation, _ := thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEDOWN)
 
 	mockResponse = []alice.MetricUpdate{
 		{Name: "metric.one", CurrentReading: 0},
 		{Name: "metric.two", CurrentReading: 10},
 	}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.HOLD)
 
 	mockResponse = []alice.MetricUpdate{
 		{Name: "metric.one", CurrentReading: 0},
 		{Name: "metric.two", CurrentReading: 20},
 	}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 	mockResponse = []alice.MetricUpdate{
 		{Name: "metric.one", CurrentReading: 10},
 		{Name: "metric.two", CurrentReading: 0},
 	}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.HOLD)
 
 	mockResponse = []alice.MetricUpdate{
 		{Name: "metric.one", CurrentReading: 20},
 		{Name: "metric.two", CurrentReading: 0},
 	}
-	recommendation, _ = thresholdStrategy.Evaluate()
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
 	assert.Equal(t, *recommendation, alice.SCALEUP)
 
 }
+
+func TestThresholdStrategy_EvaluateStep(t *testing.T) {
+	setupThresholdStrategyTest()
+
+	config.Set("thresholds.metric.one.min", 5)
+	config.Set("thresholds.metric.one.max", 15)
+	config.Set("thresholds.metric.one.step", 4)
+	config.Set("thresholds.metric.two.min", 5)
+	config.Set("thresholds.metric.two.max", 15)
+	config.Set("thresholds.metric.two.step", 2)
+
+	mockResponse = []alice.MetricUpdate{
+		{Name: "metric.one", CurrentReading: 20},
+		{Name: "metric.two", CurrentReading: 20},
+	}
+	recommendation, _ := thresholdStrategy.Evaluate(ctx)
+	assert.Equal(t, alice.Recommendation(4), *recommendation)
+
+	mockResponse = []alice.MetricUpdate{
+		{Name: "metric.one", CurrentReading: 0},
+		{Name: "metric.two", CurrentReading: 0},
+	}
+	recommendation, _ = thresholdStrategy.Evaluate(ctx)
+	assert.Equal(t, alice.Recommendation(-2), *recommendation)
+
+	config.Set("thresholds.metric.two.step", 0)
+	_, err := thresholdStrategy.Evaluate(ctx)
+	assert.Error(t, err)
+}
+
+func TestThresholdStrategy_Validate(t *testing.T) {
+	setupThresholdStrategyTest()
+	assert.Len(t, thresholdStrategy.Validate(), 1)
+	config.Set("thresholds.cpu.min", 10)
+	config.Set("thresholds.cpu.max", 80)
+	assert.Empty(t, thresholdStrategy.Validate())
+	config.Set("thresholds.cpu.step", 0)
+	config.Set("thresholds.load.invert_scaling", true)
+	config.Set("thresholds.queue.min", 10)
+	config.Set("thresholds.queue.max", 5)
+	assert.Len(t, thresholdStrategy.Validate(), 3)
+}
+
+func TestThresholdStrategy_Metrics(t *testing.T) {
+	setupThresholdStrategyTest()
+	config.Set("thresholds.memory.max", 80)
+	config.Set("thresholds.cpu.max", 80)
+	assert.Equal(t, []string{"cpu", "memory"}, thresholdStrategy.Metrics())
+}
+
+func TestThresholdStrategy_Explain(t *testing.T) {
+	setupThresholdStrategyTest()
+	config.Set("thresholds.cpu.max", 80)
+	config.Set("thresholds.memory.min", 20)
+	config.Set("thresholds.memory.max", 90)
+	config.Set("thresholds.memory.step", 2)
+	assert.Nil(t, thresholdStrategy.Explain())
+
+	mockResponse = []alice.MetricUpdate{{Name: "cpu", CurrentReading: 85.5}, {Name: "memory", CurrentReading: 95}}
+	thresholdStrategy.Evaluate(ctx)
+	evaluation := thresholdStrategy.Explain()
+	assert.Equal(t, alice.Recommendation(2), evaluation.Recommendation)
+	assert.Equal(t, "memory", evaluation.Deciding)
+	assert.Equal(t, "memory is 95, above the max of 90", evaluation.String())
+	assert.Len(t, evaluation.Metrics, 2)
+	assert.Equal(t, "cpu is 85.5, above the max of 80", evaluation.Metrics[0].Reason)
+	assert.Equal(t, alice.SCALEUP, evaluation.Metrics[0].Recommendation)
+	assert.Nil(t, evaluation.Metrics[0].Min)
+	assert.Equal(t, 80.0, *evaluation.Metrics[0].Max)
+	assert.Equal(t, 20.0, *evaluation.Metrics[1].Min)
+
+	mockResponse = []alice.MetricUpdate{{Name: "cpu", CurrentReading: 50}, {Name: "memory", CurrentReading: 10}}
+	thresholdStrategy.Evaluate(ctx)
+	assert.Equal(t, "cpu is 50, within its thresholds", thresholdStrategy.Explain().String())
+
+	mockResponse = []alice.MetricUpdate{{Name: "disk", CurrentReading: 50}}
+	thresholdStrategy.Evaluate(ctx)
+	assert.Nil(t, thresholdStrategy.Explain())
+}
+
+func TestManager_RunExplained(t *testing.T) {
+	setupThresholdStrategyTest()
+	config.Set("thresholds.cpu.max", 80)
+	config.Set("scale_up", true)
+	mockResponse = []alice.MetricUpdate{{Name: "cpu", CurrentReading: 90}}
+	inv := MockInventory{}
+	inv.On("Total").Return(10, nil)
+	inv.On("Status").Return(alice.OK, nil)
+	inv.On("Increase", 1).Return(nil)
+	notifier := MockNotifier{}
+	notifier.On("Notify", mock.Anything).Return(nil)
+	man := alice.Manager{Name: "web", Strategy: thresholdStrategy, Inventory: &inv, Logger: log, Config: config, Notifier: &notifier}
+	assert.NoError(t, man.Run(ctx))
+	assert.Equal(t, "cpu", man.Status().Evaluation.Deciding)
+	assert.Equal(t, "cpu is 90, above the max of 80", notifier.events()[0].Evaluation.String())
+}
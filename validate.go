@@ -0,0 +1,179 @@
+package alice
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Validator is implemented by plugins that can check their configuration more thoroughly than their New function
+// does. Validate must not contact any remote services. Errors about a particular key should be ConfigErrors, with the
+// key relative to the plugin's block of configuration.
+type Validator interface {
+	Validate() []error
+}
+
+// ConfigError is a problem with the value of a key in the configuration. File is the config file it came from, and
+// Value the value that is wrong, if they are known.
+type ConfigError struct {
+	File  string
+	Key   string
+	Value interface{}
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	location := e.Key
+	if e.File != "" {
+		location = e.File + ": " + e.Key
+	}
+	if e.Value != nil {
+		location += fmt.Sprintf(" (%v)", e.Value)
+	}
+	return location + ": " + e.Err.Error()
+}
+
+// configErrorf creates a ConfigError for key
+func configErrorf(key string, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{Key: key, Err: errors.Errorf(format, args...)}
+}
+
+// inConfig places errors found in a block of configuration at the given key within the whole configuration
+func inConfig(key string, errs ...error) []error {
+	placed := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if configErrs, ok := err.(ConfigErrors); ok {
+			placed = append(placed, inConfig(key, configErrs...)...)
+		} else if configErr, ok := err.(*ConfigError); ok {
+			full := key
+			if configErr.Key != "" {
+				full += "." + configErr.Key
+			}
+			placed = append(placed, &ConfigError{File: configErr.File, Key: full, Err: configErr.Err})
+		} else {
+			placed = append(placed, &ConfigError{Key: key, Err: err})
+		}
+	}
+	return placed
+}
+
+// Validate checks every manager in config, and the leader election and notifier settings, returning every problem it
+// finds rather than stopping at the first. Plugins are created, and asked to validate themselves if they are Validators, but
+// nothing is run, so no remote services are contacted. Errors are ConfigErrors in file, if one is given, with the
+// value they are about unless it is a whole block of configuration.
+func Validate(config *viper.Viper, file string, log *logrus.Entry) []error {
+	var errs []error
+	if config.IsSet("interval") && config.GetDuration("interval") <= 0 {
+		errs = append(errs, configErrorf("interval", "Must be a positive duration"))
+	}
+	if config.IsSet("leader_election") {
+		elector, err := NewElector(config.Sub("leader_election"), log)
+		if err != nil {
+			errs = append(errs, inConfig("leader_election", err)...)
+		} else if v, ok := elector.(Validator); ok {
+			errs = append(errs, inConfig("leader_election", v.Validate()...)...)
+		}
+	}
+	if config.IsSet("notifiers") {
+		if config.Sub("notifiers") == nil {
+			errs = append(errs, configErrorf("notifiers", "No notifiers defined"))
+		} else if notifications, err := NewNotifications(config.Sub("notifiers"), log); err != nil {
+			errs = append(errs, inConfig("notifiers", err)...)
+		} else {
+			errs = append(errs, inConfig("notifiers", notifications.Validate()...)...)
+		}
+	}
+	if !config.IsSet("managers") {
+		errs = append(errs, configErrorf("managers", "No managers defined"))
+	}
+	var names []string
+	for name := range config.GetStringMap("managers") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := "managers." + name
+		managerConfig := config.Sub(key)
+		if managerConfig == nil {
+			errs = append(errs, configErrorf(key, "Manager has no configuration"))
+			continue
+		}
+		errs = append(errs, inConfig(key, validateManager(managerConfig, log.WithField("manager", name))...)...)
+	}
+	for _, err := range errs {
+		configErr := err.(*ConfigError)
+		configErr.File = file
+		if value := config.Get(configErr.Key); value != nil && reflect.ValueOf(value).Kind() != reflect.Map {
+			configErr.Value = value
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].(*ConfigError).Key < errs[j].(*ConfigError).Key })
+	return errs
+}
+
+// validateManager checks a single manager's configuration
+func validateManager(config *viper.Viper, log *logrus.Entry) []error {
+	var errs []error
+	validate := func(key string, plugin interface{}, err error) {
+		if err != nil {
+			errs = append(errs, inConfig(key, err)...)
+		} else if v, ok := plugin.(Validator); ok {
+			errs = append(errs, inConfig(key, v.Validate()...)...)
+		}
+	}
+	for _, key := range []string{"inventory", "strategy"} {
+		if !config.IsSet(key) {
+			errs = append(errs, configErrorf(key, "Missing %v definition", key))
+		}
+	}
+	var inv Inventory
+	var mon Monitor
+	var err error
+	if config.IsSet("inventory") {
+		inv, err = NewInventory(config.Sub("inventory"), log)
+		validate("inventory", inv, err)
+	}
+	mon, key, err := newManagerMonitor(config, log)
+	validate(key, mon, err)
+	if config.IsSet("strategy") {
+		str, err := NewStrategy(config.Sub("strategy"), inv, mon, log)
+		validate("strategy", str, err)
+		if mode := config.GetString("strategy.mode"); mode == "target" {
+			if _, ok := str.(TargetStrategy); err == nil && !ok {
+				errs = append(errs, configErrorf("strategy.mode", "The %s strategy does not support target mode", config.GetString("strategy.name")))
+			}
+		} else if mode != "" && mode != "direction" {
+			errs = append(errs, configErrorf("strategy.mode", "Unknown strategy mode: %s", mode))
+		}
+	}
+	if _, err := newWindows(config); err != nil {
+		errs = append(errs, inConfig("schedules", err)...)
+	}
+	if config.IsSet("interval") && config.GetDuration("interval") <= 0 {
+		errs = append(errs, configErrorf("interval", "Must be a positive duration"))
+	}
+	for _, key := range []string{"min_total", "max_total", "max_step_up", "max_step_down"} {
+		if config.IsSet(key) && config.GetInt(key) < 0 {
+			errs = append(errs, configErrorf(key, "Can't be negative"))
+		}
+	}
+	for _, key := range []string{"scale_up", "scale_down"} {
+		if _, err := cast.ToBoolE(config.Get(key)); config.IsSet(key) && err != nil && config.GetString(key) != "approval" {
+			errs = append(errs, configErrorf(key, "Must be true, false or approval"))
+		}
+	}
+	for _, key := range []string{"approval_timeout", "max_metric_age"} {
+		if config.IsSet(key) && config.GetDuration(key) <= 0 {
+			errs = append(errs, configErrorf(key, "Must be a positive duration"))
+		}
+	}
+	if config.IsSet("min_total") && config.IsSet("max_total") && config.GetInt("min_total") > config.GetInt("max_total") {
+		errs = append(errs, configErrorf("min_total", "Is more than max_total"))
+	}
+	return errs
+}
@@ -0,0 +1,64 @@
+package alice_test
+
+import (
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	config := viper.New()
+	config.Set("interval", "1m")
+	config.Set("managers", map[string]interface{}{
+		"web": map[string]interface{}{
+			"inventory": map[string]interface{}{"name": "mock"},
+			"monitor":   map[string]interface{}{"name": "mock"},
+			"strategy": map[string]interface{}{
+				"name":       "threshold",
+				"mode":       "target",
+				"thresholds": map[string]interface{}{"cpu": map[string]interface{}{"step": 2}},
+			},
+			"min_total":      5,
+			"max_total":      2,
+			"scale_up":       "maybe",
+			"max_metric_age": "-5m",
+		},
+		"workers": map[string]interface{}{
+			"inventory": map[string]interface{}{"name": "unknown"},
+			"strategy":  map[string]interface{}{"name": "mock"},
+			"schedules": map[string]interface{}{"nights": map[string]interface{}{"duration": "1h"}},
+		},
+	})
+	errs := alice.Validate(config, "config.yaml", log)
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+		assert.Equal(t, "config.yaml", err.(*alice.ConfigError).File)
+	}
+	keys := []string{
+		"managers.web.max_metric_age",
+		"managers.web.min_total",
+		"managers.web.scale_up",
+		"managers.web.strategy.mode",
+		"managers.web.strategy.thresholds.cpu",
+		"managers.workers.inventory",
+		"managers.workers.monitor",
+		"managers.workers.schedules",
+	}
+	if assert.Len(t, errs, len(keys), "%v", messages) {
+		for i, key := range keys {
+			assert.Equal(t, key, errs[i].(*alice.ConfigError).Key)
+		}
+	}
+	assert.Equal(t, "config.yaml: managers.web.min_total (5): Is more than max_total", messages[1])
+	assert.Equal(t, "config.yaml: managers.web.max_metric_age (-5m): Must be a positive duration", messages[0])
+	assert.Nil(t, errs[4].(*alice.ConfigError).Value, "blocks of configuration aren't shown")
+}
+
+func TestValidateNoManagers(t *testing.T) {
+	errs := alice.Validate(viper.New(), "", log)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "managers: No managers defined", errs[0].Error())
+}
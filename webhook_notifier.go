@@ -0,0 +1,39 @@
+package alice
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// WebhookNotifier POSTs each event to a URL as JSON
+type WebhookNotifier struct {
+	log    *logrus.Entry
+	config WebhookConfig
+	client *http.Client
+}
+
+// WebhookConfig is the configuration of a WebhookNotifier
+type WebhookConfig struct {
+	URL     string            `config:"url" required:"true" description:"URL to POST each event to, as JSON"`
+	Headers map[string]string `config:"headers" description:"Extra HTTP headers to send, eg for authentication"`
+	Timeout time.Duration     `config:"timeout" default:"10s" description:"Give up on a request after this long"`
+}
+
+// NewWebhookNotifier creates a new Notifier
+func NewWebhookNotifier(config *viper.Viper, log *logrus.Entry) (Notifier, error) {
+	var c WebhookConfig
+	if err := LoadConfig(config, &c); err != nil {
+		return nil, err
+	}
+	return &WebhookNotifier{log: log, config: c, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+// Notify sends the event
+func (w *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	w.log.Debugf("Sending %s event to %s", event.Type, w.config.URL)
+	return postJSON(ctx, w.client, w.config.URL, w.config.Headers, event)
+}
@@ -0,0 +1,43 @@
+package alice_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/notonthehighstreet/alice"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received alice.Event
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	config := viper.New()
+	config.Set("url", server.URL)
+	config.Set("headers", map[string]interface{}{"Authorization": "Bearer secret"})
+	notifier, err := alice.NewWebhookNotifier(config, log)
+	assert.NoError(t, err)
+	event := alice.Event{Type: alice.EventScaledUp, Manager: "web", Step: 2, Message: "Scaled web up by 2"}
+	assert.NoError(t, notifier.Notify(ctx, event))
+	assert.Equal(t, event, received)
+	assert.Equal(t, "Bearer secret", token)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	config.Set("url", failing.URL)
+	notifier, _ = alice.NewWebhookNotifier(config, log)
+	assert.EqualError(t, notifier.Notify(ctx, event), "Unexpected response: 502 Bad Gateway")
+
+	_, err = alice.NewWebhookNotifier(viper.New(), log)
+	assert.EqualError(t, err, "url: Must be set")
+}